@@ -26,7 +26,10 @@ func init() {
 	ioc.Put(NewSystem, ioc.Name("api.system"))
 	ioc.Put(NewTask, ioc.Name("api.task"))
 	ioc.Put(NewJob, ioc.Name("api.job"))
+	ioc.Put(NewWorkflow, ioc.Name("api.workflow"))
+	ioc.Put(NewCalendar, ioc.Name("api.calendar"))
 	ioc.Put(NewUser, ioc.Name("api.user"))
 	ioc.Put(NewRole, ioc.Name("api.role"))
 	ioc.Put(NewConfig, ioc.Name("api.config"))
+	ioc.Put(NewRunner, ioc.Name("api.runner"))
 }
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/schedule"
+	"github.com/cuigh/skynet/store"
+)
+
+// CalendarHandler encapsulates calendar related handlers.
+type CalendarHandler struct {
+	Search web.HandlerFunc `path:"/search" auth:"?" desc:"search calendars"`
+	Find   web.HandlerFunc `path:"/find" auth:"?" desc:"find calendar by name"`
+	Save   web.HandlerFunc `path:"/save" method:"post" auth:"calendar.edit" desc:"create or update calendar"`
+	Delete web.HandlerFunc `path:"/delete" method:"post" auth:"calendar.delete" desc:"delete calendar"`
+}
+
+// NewCalendar creates an instance of CalendarHandler
+func NewCalendar(cs store.CalendarStore, ts store.TaskStore) *CalendarHandler {
+	return &CalendarHandler{
+		Search: calendarSearch(cs),
+		Find:   calendarFind(cs),
+		Save:   calendarSave(cs),
+		Delete: calendarDelete(cs, ts),
+	}
+}
+
+func calendarSearch(cs store.CalendarStore) web.HandlerFunc {
+	type Args struct {
+		Name      string `json:"name"`
+		PageIndex int64  `json:"page_index"`
+		PageSize  int64  `json:"page_size"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+
+		calendars, total, err := cs.Search(args.Name, args.PageIndex, args.PageSize)
+		if err != nil {
+			return err
+		}
+		return success(ctx, data.Map{"items": calendars, "total": total})
+	}
+}
+
+func calendarFind(cs store.CalendarStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		name := ctx.Query("name")
+		c, err := cs.Find(name)
+		if err != nil {
+			return err
+		}
+		return success(ctx, c)
+	}
+}
+
+func calendarSave(cs store.CalendarStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		c := &store.Calendar{}
+		err := ctx.Bind(c, true)
+		if err == nil {
+			err = schedule.CheckCalendar(c)
+		}
+		if err == nil {
+			if time.Time(c.ModifyTime).IsZero() {
+				err = cs.Create(c)
+			} else {
+				err = cs.Modify(c)
+			}
+		}
+		return ajax(ctx, err)
+	}
+}
+
+func calendarDelete(cs store.CalendarStore, ts store.TaskStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		c := &store.Calendar{}
+		err := ctx.Bind(c)
+		if err != nil {
+			return err
+		}
+
+		// tasks referencing a missing calendar can't be scheduled
+		n, err := ts.CountByCalendar(c.Name)
+		if err != nil {
+			return err
+		} else if n > 0 {
+			return errors.Format("日历 %s 正在被 %d 个任务使用", c.Name, n)
+		}
+		return ajax(ctx, cs.Delete(c.Name))
+	}
+}
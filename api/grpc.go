@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cuigh/auxo/ext/times"
+	"github.com/cuigh/skynet/auth"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/contract/pb"
+	"github.com/cuigh/skynet/schedule"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// schedulerServer serves pb.SchedulerServer, runners report status of jobs through it like /api/task/notify
+// and /api/task/heartbeat.
+type schedulerServer struct {
+	pb.UnimplementedSchedulerServer
+	s *schedule.Scheduler
+}
+
+// NewGRPCServer creates gRPC server of scheduler, runners must be authenticated by token like HTTP API.
+func NewGRPCServer(s *schedule.Scheduler, jwt *auth.JWT, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append(opts, grpc.ChainUnaryInterceptor(authenticate(jwt)))...)
+	pb.RegisterSchedulerServer(server, &schedulerServer{s: s})
+	return server
+}
+
+func (ss *schedulerServer) Notify(_ context.Context, p *pb.NotifyParam) (*pb.Result, error) {
+	err := ss.s.Notify(p.Id, p.Attempt, p.Code, p.Info, times.FromUnixMilli(p.Start), times.FromUnixMilli(p.End))
+	return grpcResult(err), nil
+}
+
+func (ss *schedulerServer) Heartbeat(_ context.Context, p *pb.HeartbeatParam) (*pb.Result, error) {
+	return grpcResult(ss.s.Heartbeat(p.Id, p.Runner)), nil
+}
+
+func grpcResult(err error) *pb.Result {
+	if err != nil {
+		return &pb.Result{Code: contract.CodeFailed, Info: err.Error()}
+	}
+	return &pb.Result{}
+}
+
+// authenticate checks token in metadata `authorization: Bearer <token>`.
+func authenticate(jwt *auth.JWT) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || !strings.HasPrefix(values[0], jwt.Schema+" ") {
+			return nil, status.Error(codes.Unauthenticated, "token is missing")
+		}
+		if _, err := jwt.Verify(values[0][len(jwt.Schema)+1:]); err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -10,17 +10,21 @@ import (
 
 // JobHandler encapsulates job related handlers.
 type JobHandler struct {
-	Search web.HandlerFunc `path:"/search" auth:"?" desc:"search jobs"`
-	Find   web.HandlerFunc `path:"/find" auth:"?" desc:"find job by id"`
-	Retry  web.HandlerFunc `path:"/retry" method:"post" auth:"job.exec" desc:"retry job"`
+	Search   web.HandlerFunc `path:"/search" auth:"?" desc:"search jobs"`
+	Find     web.HandlerFunc `path:"/find" auth:"?" desc:"find job by id"`
+	Children web.HandlerFunc `path:"/children" auth:"?" desc:"fetch batch jobs of parallel job"`
+	Retry    web.HandlerFunc `path:"/retry" method:"post" auth:"job.exec" desc:"retry job"`
+	Cancel   web.HandlerFunc `path:"/cancel" method:"post" auth:"job.cancel" desc:"cancel running job"`
 }
 
 // NewJob creates an instance of JobHandler
 func NewJob(store store.JobStore) *JobHandler {
 	return &JobHandler{
-		Search: jobSearch(store),
-		Find:   jobFind(store),
-		Retry:  jobRetry(),
+		Search:   jobSearch(store),
+		Find:     jobFind(store),
+		Children: jobChildren(store),
+		Retry:    jobRetry(),
+		Cancel:   jobCancel(),
 	}
 }
 
@@ -61,6 +65,18 @@ func jobFind(s store.JobStore) web.HandlerFunc {
 	}
 }
 
+func jobChildren(s store.JobStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		id := ctx.Query("id")
+		jobs, err := s.FetchChildren(id)
+		if err != nil {
+			return err
+		}
+
+		return success(ctx, jobs)
+	}
+}
+
 func jobRetry() web.HandlerFunc {
 	type Args struct {
 		Id string `json:"id"`
@@ -77,3 +93,20 @@ func jobRetry() web.HandlerFunc {
 		return ajax(ctx, err)
 	}
 }
+
+func jobCancel() web.HandlerFunc {
+	type Args struct {
+		Id string `json:"id"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err == nil {
+			err = ioc.Call(func(s *schedule.Scheduler) error {
+				return s.Cancel(args.Id)
+			})
+		}
+		return ajax(ctx, err)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/app/ioc"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/schedule"
+)
+
+const (
+	pollWait    = 20 * time.Second
+	pollMaxWait = 30 * time.Second
+)
+
+// RunnerHandler serves runners in pull mode, whose tasks are dispatched by `pull://{group}`. Runners must be
+// authenticated by `skynet.token`, otherwise anyone could pull jobs and their args.
+type RunnerHandler struct {
+	Poll web.HandlerFunc `path:"/poll" method:"post" auth:"?" desc:"pull a job of group by long polling"`
+	Ack  web.HandlerFunc `path:"/ack" method:"post" auth:"?" desc:"acknowledge a pulled job is finished"`
+}
+
+// NewRunner creates an instance of RunnerHandler
+func NewRunner() *RunnerHandler {
+	return &RunnerHandler{
+		Poll: runnerPoll,
+		Ack:  runnerAck,
+	}
+}
+
+// runnerPoll waits until a job is pulled or wait time is out, data is empty if there is no job.
+func runnerPoll(ctx web.Context) error {
+	args := &contract.PullParam{}
+	err := ctx.Bind(args)
+	if err != nil {
+		return err
+	} else if args.Group == "" {
+		return errors.New("group is required")
+	}
+
+	wait := time.Duration(args.Wait) * time.Second
+	if wait <= 0 {
+		wait = pollWait
+	} else if wait > pollMaxWait {
+		wait = pollMaxWait
+	}
+	deadline := time.Now().Add(wait)
+
+	return ioc.Call(func(s *schedule.Scheduler) error {
+		for {
+			job, err := s.Pull(args.Group, args.Handlers, args.Runner)
+			if err != nil {
+				return err
+			} else if job != nil || !time.Now().Before(deadline) {
+				return success(ctx, job)
+			}
+
+			select {
+			case <-ctx.Request().Context().Done():
+				// runner is gone, a job pulled now would wait for visibility timeout
+				return nil
+			case <-time.After(time.Second):
+			}
+		}
+	})
+}
+
+func runnerAck(ctx web.Context) error {
+	args := &contract.AckParam{}
+	err := ctx.Bind(args)
+	if err == nil {
+		err = ioc.Call(func(s *schedule.Scheduler) error {
+			return s.Ack(args.Id, args.Receipt)
+		})
+	}
+	return ajax(ctx, err)
+}
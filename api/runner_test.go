@@ -0,0 +1,45 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/auth"
+)
+
+func TestRunnerRequiresToken(t *testing.T) {
+	jwt := auth.NewAuthenticator().(*auth.JWT)
+	token, err := jwt.CreateToken("runner", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := web.Default()
+	ws.Group("/api", jwt, auth.NewAuthorizer(nil, nil)).Handle("/runner", NewRunner())
+	request := func(path, token string) int {
+		r := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{}`))
+		r.Header.Set(web.HeaderContentType, web.MIMEApplicationJSON)
+		if token != "" {
+			r.Header.Set(web.HeaderAuthorization, "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		ws.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for _, path := range []string{"/api/runner/poll", "/api/runner/ack"} {
+		if code := request(path, ""); code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected %d without token, got %d", path, http.StatusUnauthorized, code)
+		}
+		if code := request(path, "invalid"); code != http.StatusUnauthorized {
+			t.Fatalf("%s: expected %d with invalid token, got %d", path, http.StatusUnauthorized, code)
+		}
+	}
+	// request with token passes authorization, and is rejected by handler for missing group
+	if code := request("/api/runner/poll", token); code == http.StatusUnauthorized {
+		t.Fatal("request with valid token should be authorized")
+	}
+}
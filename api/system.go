@@ -23,6 +23,7 @@ type SystemHandler struct {
 	InitDB     web.HandlerFunc `path:"/init-db" method:"post" auth:"*" desc:"initialize database"`
 	InitUser   web.HandlerFunc `path:"/init-user" method:"post" auth:"*" desc:"initialize administrator account"`
 	Summarize  web.HandlerFunc `path:"/summarize" auth:"?" desc:"fetch statistics data"`
+	Nodes      web.HandlerFunc `path:"/nodes" auth:"?" desc:"fetch scheduler nodes"`
 }
 
 // NewSystem creates an instance of SystemHandler
@@ -32,6 +33,7 @@ func NewSystem() *SystemHandler {
 		InitDB:     systemInitDB,
 		InitUser:   systemInitUser,
 		Summarize:  systemSummarize,
+		Nodes:      systemNodes,
 	}
 }
 
@@ -51,7 +53,8 @@ func systemCheckState(c web.Context) error {
 }
 
 func systemInitDB(ctx web.Context) error {
-	return ajax(ctx, ioc.Call(func(js store.JobStore, ls store.LockStore, us store.UserStore) error {
+	return ajax(ctx, ioc.Call(func(js store.JobStore, ls store.LockStore, us store.UserStore, rs store.RunStore, ns store.NodeStore,
+		ps store.PullStore, ss store.SlotStore) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -59,6 +62,10 @@ func systemInitDB(ctx web.Context) error {
 			func() error { return js.CreateIndexes(ctx) },
 			func() error { return ls.CreateIndexes(ctx) },
 			func() error { return us.CreateIndexes(ctx) },
+			func() error { return rs.CreateIndexes(ctx) },
+			func() error { return ns.CreateIndexes(ctx) },
+			func() error { return ps.CreateIndexes(ctx) },
+			func() error { return ss.CreateIndexes(ctx) },
 		)
 	}))
 }
@@ -137,3 +144,28 @@ func systemSummarize(c web.Context) error {
 	}
 	return success(c, summary)
 }
+
+func systemNodes(c web.Context) error {
+	type Node struct {
+		*store.Node
+		Stale bool `json:"stale"` // node missed heartbeats, it may be dead or blocked
+	}
+
+	var nodes []*Node
+	err := ioc.Call(func(ns store.NodeStore) error {
+		list, err := ns.FetchAlive(time.Time{})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, n := range list {
+			nodes = append(nodes, &Node{Node: n, Stale: n.Stale(now)})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return success(c, nodes)
+}
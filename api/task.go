@@ -3,8 +3,8 @@ package api
 import (
 	"github.com/cuigh/auxo/app/ioc"
 	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/auxo/errors"
 	"github.com/cuigh/auxo/ext/times"
-	"github.com/cuigh/auxo/log"
 	"github.com/cuigh/auxo/net/web"
 	"github.com/cuigh/skynet/contract"
 	"github.com/cuigh/skynet/schedule"
@@ -14,27 +14,35 @@ import (
 
 // TaskHandler is a controller of task.
 type TaskHandler struct {
-	Search  web.HandlerFunc `path:"/search" auth:"?" desc:"search tasks"`
-	Find    web.HandlerFunc `path:"/find" auth:"?" desc:"find task by name"`
-	Save    web.HandlerFunc `path:"/save" method:"post" auth:"task.edit" desc:"create or update task"`
-	Delete  web.HandlerFunc `path:"/delete" method:"post" auth:"task.delete" desc:"delete task"`
-	Execute web.HandlerFunc `path:"/execute" method:"post" auth:"task.exec" desc:"execute task"`
-	Notify  web.HandlerFunc `path:"/notify" method:"post" auth:"*" desc:"notify execution result"`
+	Search    web.HandlerFunc `path:"/search" auth:"?" desc:"search tasks"`
+	Find      web.HandlerFunc `path:"/find" auth:"?" desc:"find task by name"`
+	Preview   web.HandlerFunc `path:"/preview" method:"post" auth:"?" desc:"preview fire times of triggers"`
+	Save      web.HandlerFunc `path:"/save" method:"post" auth:"task.edit" desc:"create or update task"`
+	Delete    web.HandlerFunc `path:"/delete" method:"post" auth:"task.delete" desc:"delete task"`
+	Execute   web.HandlerFunc `path:"/execute" method:"post" auth:"task.exec" desc:"execute task"`
+	Pause     web.HandlerFunc `path:"/pause" method:"post" auth:"task.pause" desc:"pause task"`
+	Resume    web.HandlerFunc `path:"/resume" method:"post" auth:"task.pause" desc:"resume paused task"`
+	Notify    web.HandlerFunc `path:"/notify" method:"post" auth:"*" desc:"notify execution result"`
+	Heartbeat web.HandlerFunc `path:"/heartbeat" method:"post" auth:"*" desc:"report job is still running"`
 }
 
 // NewTask creates an instance of TaskHandler
-func NewTask(store store.TaskStore, js store.JobStore) *TaskHandler {
+func NewTask(store store.TaskStore, cs store.CalendarStore) *TaskHandler {
 	return &TaskHandler{
-		Search:  taskSearch(store),
-		Find:    taskFind(store),
-		Save:    taskSave(store),
-		Delete:  taskDelete(store),
-		Execute: taskExecute(),
-		Notify:  taskNotify(js),
+		Search:    taskSearch(store, cs),
+		Find:      taskFind(store, cs),
+		Preview:   taskPreview(cs),
+		Save:      taskSave(store, cs),
+		Delete:    taskDelete(store),
+		Execute:   taskExecute(),
+		Pause:     taskPause(store),
+		Resume:    taskResume(store),
+		Notify:    taskNotify(),
+		Heartbeat: taskHeartbeat(),
 	}
 }
 
-func taskSearch(ts store.TaskStore) web.HandlerFunc {
+func taskSearch(ts store.TaskStore, cs store.CalendarStore) web.HandlerFunc {
 	type Args struct {
 		Name      string `json:"name"`
 		Runner    string `json:"runner"`
@@ -53,25 +61,99 @@ func taskSearch(ts store.TaskStore) web.HandlerFunc {
 		if err != nil {
 			return err
 		}
-		return success(ctx, data.Map{"items": tasks, "total": total})
+		return success(ctx, data.Map{"items": withNextFire(cs, tasks), "total": total})
 	}
 }
 
-func taskFind(ts store.TaskStore) web.HandlerFunc {
+func taskFind(ts store.TaskStore, cs store.CalendarStore) web.HandlerFunc {
 	return func(ctx web.Context) error {
 		name := ctx.Query("name")
 		task, err := ts.Find(name)
 		if err != nil {
 			return err
 		}
-		return success(ctx, task)
+		return success(ctx, withNextFire(cs, []*store.Task{task})[0])
 	}
 }
 
-func taskSave(ts store.TaskStore) web.HandlerFunc {
+func taskPreview(cs store.CalendarStore) web.HandlerFunc {
+	type Args struct {
+		Name      string   `json:"name"` // seed of H fields
+		Triggers  []string `json:"triggers"`
+		TimeZone  string   `json:"timezone"`
+		Calendars []string `json:"calendars"`
+		Count     int      `json:"count"`
+		Lang      string   `json:"lang"` // language of descriptions, zh(default) or en
+	}
+	type Trigger struct {
+		Expr  string `json:"expr"`
+		Desc  string `json:"desc,omitempty"`
+		Error string `json:"error,omitempty"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+		if args.Count <= 0 || args.Count > 100 {
+			args.Count = 10
+		}
+
+		loc, err := schedule.LoadLocation(args.TimeZone)
+		if err != nil {
+			return errors.Format("无效的时区: %s", args.TimeZone)
+		}
+
+		valid := true
+		triggers := make([]*Trigger, len(args.Triggers))
+		for i, expr := range args.Triggers {
+			triggers[i] = &Trigger{Expr: expr}
+			if _, err = schedule.ParseTrigger(expr, loc, args.Name); err != nil {
+				triggers[i].Error, valid = err.Error(), false
+			} else {
+				// H fields are described with resolved values
+				expanded, _ := schedule.ExpandTrigger(expr, args.Name)
+				triggers[i].Desc = schedule.DescribeTrigger(expanded, args.Lang)
+			}
+		}
+
+		fires := []store.Time{}
+		if valid && len(triggers) > 0 {
+			calendars, err := cs.FetchMany(args.Calendars)
+			if err != nil {
+				return err
+			}
+
+			t := &store.Task{Name: args.Name, Triggers: args.Triggers, TimeZone: args.TimeZone, Calendars: args.Calendars}
+			times, err := schedule.NextFireTimes(t, calendars, time.Now(), args.Count)
+			if err != nil {
+				return err
+			}
+			for _, ft := range times {
+				fires = append(fires, store.Time(ft))
+			}
+		}
+		return success(ctx, data.Map{"triggers": triggers, "fires": fires})
+	}
+}
+
+func taskSave(ts store.TaskStore, cs store.CalendarStore) web.HandlerFunc {
 	return func(ctx web.Context) error {
 		t := &store.Task{}
 		err := ctx.Bind(t, true)
+		if err == nil {
+			_, err = schedule.LoadLocation(t.TimeZone)
+		}
+		var calendars map[string]*store.Calendar
+		if err == nil {
+			calendars, err = cs.FetchMany(t.Calendars)
+		}
+		if err == nil {
+			// check triggers and calendars
+			_, err = schedule.NewItem(t, calendars)
+		}
 		if err == nil {
 			if time.Time(t.ModifyTime).IsZero() {
 				err = ts.Create(t)
@@ -83,6 +165,34 @@ func taskSave(ts store.TaskStore) web.HandlerFunc {
 	}
 }
 
+// taskItem is a task with its next fire time.
+type taskItem struct {
+	*store.Task
+	NextFire *store.Time `json:"next_fire,omitempty"`
+}
+
+// withNextFire computes next fire times of tasks, it is absent if task is disabled, paused or fixed-delay.
+func withNextFire(cs store.CalendarStore, tasks []*store.Task) []*taskItem {
+	var names []string
+	for _, t := range tasks {
+		names = append(names, t.Calendars...)
+	}
+	calendars, err := cs.FetchMany(names)
+
+	now := time.Now()
+	items := make([]*taskItem, len(tasks))
+	for i, t := range tasks {
+		items[i] = &taskItem{Task: t}
+		if err != nil || !t.Enabled || t.Pause != nil {
+			continue
+		}
+		if next, err := schedule.NextFireTime(t, calendars, now); err == nil && !next.IsZero() {
+			items[i].NextFire = (*store.Time)(&next)
+		}
+	}
+	return items
+}
+
 func taskDelete(ts store.TaskStore) web.HandlerFunc {
 	return func(ctx web.Context) error {
 		t := &store.Task{}
@@ -95,30 +205,92 @@ func taskDelete(ts store.TaskStore) web.HandlerFunc {
 }
 
 func taskExecute() web.HandlerFunc {
+	return func(ctx web.Context) error {
+		args := &contract.ExecuteParam{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+
+		at := time.Now()
+		if args.At > 0 {
+			at = times.FromUnixMilli(args.At)
+		} else if args.Delay > 0 {
+			at = at.Add(time.Duration(args.Delay) * time.Second)
+		}
+
+		var id string
+		err = ioc.Call(func(s *schedule.Scheduler) (err error) {
+			id, err = s.Submit(args.Name, args.Args, at)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		return success(ctx, &contract.ExecuteResult{Id: id})
+	}
+}
+
+func taskPause(ts store.TaskStore) web.HandlerFunc {
 	type Args struct {
-		Name string       `json:"name"`
-		Args data.Options `json:"args"`
+		Name   string `json:"name" valid:"required"`
+		Reason string `json:"reason" valid:"required"`
+		Until  int64  `json:"until,omitempty"` // unix milliseconds, 0 means never resume automatically
 	}
 
 	return func(ctx web.Context) error {
 		args := &Args{}
-		err := ctx.Bind(args)
-		if err == nil {
-			err = ioc.Call(func(s *schedule.Scheduler) error {
-				return s.Execute(args.Name, args.Args)
-			})
+		err := ctx.Bind(args, true)
+		if err != nil {
+			return err
 		}
-		return ajax(ctx, err)
+
+		r := &store.PauseRecord{
+			User:     ctx.User().ID(),
+			UserName: ctx.User().Name(),
+			Reason:   args.Reason,
+		}
+		if args.Until > 0 {
+			until := times.FromUnixMilli(args.Until)
+			if !until.After(time.Now()) {
+				return errors.New("恢复时间必须晚于当前时间")
+			}
+			r.Until = (*store.Time)(&until)
+		}
+		return ajax(ctx, ts.Pause(args.Name, r))
 	}
 }
 
-func taskNotify(js store.JobStore) web.HandlerFunc {
+func taskResume(ts store.TaskStore) web.HandlerFunc {
 	type Args struct {
-		Code  int32  `json:"code"`
-		Info  string `json:"info,omitempty"`
-		Id    string `json:"id"`
-		Start int64  `json:"start,omitempty"` // unix milliseconds
-		End   int64  `json:"end,omitempty"`   // unix milliseconds
+		Name   string `json:"name" valid:"required"`
+		Reason string `json:"reason,omitempty"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args, true)
+		if err != nil {
+			return err
+		}
+
+		r := &store.PauseRecord{
+			User:     ctx.User().ID(),
+			UserName: ctx.User().Name(),
+			Reason:   args.Reason,
+		}
+		return ajax(ctx, ts.Resume(args.Name, r))
+	}
+}
+
+func taskNotify() web.HandlerFunc {
+	type Args struct {
+		Code    int32  `json:"code"`
+		Info    string `json:"info,omitempty"`
+		Id      string `json:"id"`
+		Attempt int32  `json:"attempt,omitempty"`
+		Start   int64  `json:"start,omitempty"` // unix milliseconds
+		End     int64  `json:"end,omitempty"`   // unix milliseconds
 	}
 
 	return func(ctx web.Context) error {
@@ -130,17 +302,25 @@ func taskNotify(js store.JobStore) web.HandlerFunc {
 
 		start := times.FromUnixMilli(args.Start)
 		end := times.FromUnixMilli(args.End)
-		if err = js.ModifyExecute(args.Id, args.Code == 0, args.Info, start, end); err != nil {
+		err = ioc.Call(func(s *schedule.Scheduler) error {
+			return s.Notify(args.Id, args.Attempt, args.Code, args.Info, start, end)
+		})
+		if err != nil {
 			return err
 		}
-		if args.Code != contract.CodeSuccess {
-			err = ioc.Call(func(alerter *schedule.Alerter) {
-				go alerter.Alert(args.Id, args.Info)
+		return success(ctx, nil)
+	}
+}
+
+func taskHeartbeat() web.HandlerFunc {
+	return func(ctx web.Context) error {
+		args := &contract.HeartbeatParam{}
+		err := ctx.Bind(args)
+		if err == nil {
+			err = ioc.Call(func(s *schedule.Scheduler) error {
+				return s.Heartbeat(args.Id, args.Runner)
 			})
-			if err != nil {
-				log.Get("api").Error(err)
-			}
 		}
-		return success(ctx, nil)
+		return ajax(ctx, err)
 	}
 }
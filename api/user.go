@@ -196,7 +196,7 @@ func userModifyPassword(s store.UserStore) web.HandlerFunc {
 		if err != nil {
 			return err
 		} else if user == nil {
-			return errors.Format("未找到用户: %d", id)
+			return errors.Format("未找到用户: %s", id)
 		}
 
 		if !passwd.Validate(args.OldPassword, user.Password, user.Salt) {
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/app/ioc"
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/schedule"
+	"github.com/cuigh/skynet/store"
+)
+
+// WorkflowHandler encapsulates workflow related handlers.
+type WorkflowHandler struct {
+	Search  web.HandlerFunc `path:"/search" auth:"?" desc:"search workflows"`
+	Find    web.HandlerFunc `path:"/find" auth:"?" desc:"find workflow by name"`
+	Save    web.HandlerFunc `path:"/save" method:"post" auth:"workflow.edit" desc:"create or update workflow"`
+	Delete  web.HandlerFunc `path:"/delete" method:"post" auth:"workflow.delete" desc:"delete workflow"`
+	Execute web.HandlerFunc `path:"/execute" method:"post" auth:"workflow.exec" desc:"execute workflow"`
+	Runs    web.HandlerFunc `path:"/runs" auth:"?" desc:"search runs of workflow"`
+	Run     web.HandlerFunc `path:"/run" auth:"?" desc:"find workflow run by id"`
+	Stop    web.HandlerFunc `path:"/stop" method:"post" auth:"workflow.exec" desc:"stop workflow run"`
+}
+
+// NewWorkflow creates an instance of WorkflowHandler
+func NewWorkflow(ws store.WorkflowStore, rs store.RunStore, ts store.TaskStore) *WorkflowHandler {
+	return &WorkflowHandler{
+		Search:  workflowSearch(ws),
+		Find:    workflowFind(ws),
+		Save:    workflowSave(ws, ts),
+		Delete:  workflowDelete(ws),
+		Execute: workflowExecute(),
+		Runs:    workflowRuns(rs),
+		Run:     workflowRun(rs),
+		Stop:    workflowStop(),
+	}
+}
+
+func workflowSearch(ws store.WorkflowStore) web.HandlerFunc {
+	type Args struct {
+		Name      string `json:"name"`
+		PageIndex int64  `json:"page_index"`
+		PageSize  int64  `json:"page_size"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+
+		workflows, total, err := ws.Search(args.Name, args.PageIndex, args.PageSize)
+		if err != nil {
+			return err
+		}
+		return success(ctx, data.Map{"items": workflows, "total": total})
+	}
+}
+
+func workflowFind(ws store.WorkflowStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		name := ctx.Query("name")
+		w, err := ws.Find(name)
+		if err != nil {
+			return err
+		}
+		return success(ctx, w)
+	}
+}
+
+func workflowSave(ws store.WorkflowStore, ts store.TaskStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		w := &store.Workflow{}
+		err := ctx.Bind(w, true)
+		if err == nil {
+			err = validateWorkflow(w, ts)
+		}
+		if err == nil {
+			if time.Time(w.ModifyTime).IsZero() {
+				err = ws.Create(w)
+			} else {
+				err = ws.Modify(w)
+			}
+		}
+		return ajax(ctx, err)
+	}
+}
+
+func workflowDelete(ws store.WorkflowStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		w := &store.Workflow{}
+		err := ctx.Bind(w)
+		if err == nil {
+			err = ws.Delete(w.Name)
+		}
+		return ajax(ctx, err)
+	}
+}
+
+func workflowExecute() web.HandlerFunc {
+	type Args struct {
+		Name string `json:"name"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+
+		var id string
+		err = ioc.Call(func(s *schedule.Scheduler) (err error) {
+			id, err = s.ExecuteWorkflow(args.Name)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		return success(ctx, data.Map{"id": id})
+	}
+}
+
+func workflowRuns(rs store.RunStore) web.HandlerFunc {
+	type Args struct {
+		Workflow  string `json:"workflow"`
+		PageIndex int64  `json:"page_index"`
+		PageSize  int64  `json:"page_size"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err != nil {
+			return err
+		}
+
+		runs, total, err := rs.Search(args.Workflow, args.PageIndex, args.PageSize)
+		if err != nil {
+			return err
+		}
+		return success(ctx, data.Map{"items": runs, "total": total})
+	}
+}
+
+func workflowRun(rs store.RunStore) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		id := ctx.Query("id")
+		run, err := rs.Find(id)
+		if err != nil {
+			return err
+		}
+		return success(ctx, run)
+	}
+}
+
+func workflowStop() web.HandlerFunc {
+	type Args struct {
+		Id string `json:"id"`
+	}
+
+	return func(ctx web.Context) error {
+		args := &Args{}
+		err := ctx.Bind(args)
+		if err == nil {
+			err = ioc.Call(func(s *schedule.Scheduler) error {
+				return s.StopRun(args.Id)
+			})
+		}
+		return ajax(ctx, err)
+	}
+}
+
+// validateWorkflow checks that node ids are unique, tasks exist and dependencies form a DAG.
+func validateWorkflow(w *store.Workflow, ts store.TaskStore) error {
+	if len(w.Nodes) == 0 {
+		return errors.New("工作流至少需要一个节点")
+	}
+
+	degrees := make(map[string]int)
+	downstreams := make(map[string][]string)
+	for _, n := range w.Nodes {
+		if n.Id == "" {
+			return errors.New("节点 ID 不能为空")
+		}
+		if _, ok := degrees[n.Id]; ok {
+			return errors.Format("节点 ID %s 重复", n.Id)
+		}
+		if _, err := ts.Find(n.Task); err != nil {
+			return errors.Format("节点 %s 的任务 %s 不存在", n.Id, n.Task)
+		}
+		degrees[n.Id] = len(n.Depends)
+	}
+
+	for _, n := range w.Nodes {
+		for _, d := range n.Depends {
+			if _, ok := degrees[d]; !ok {
+				return errors.Format("节点 %s 依赖的节点 %s 不存在", n.Id, d)
+			}
+			downstreams[d] = append(downstreams[d], n.Id)
+		}
+	}
+
+	// Kahn's algorithm: all nodes can be sorted only if there is no cycle
+	var queue []string
+	for id, degree := range degrees {
+		if degree == 0 {
+			queue = append(queue, id)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, down := range downstreams[id] {
+			if degrees[down]--; degrees[down] == 0 {
+				queue = append(queue, down)
+			}
+		}
+	}
+	if visited != len(w.Nodes) {
+		return errors.New("节点依赖存在循环")
+	}
+	return nil
+}
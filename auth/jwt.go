@@ -90,6 +90,15 @@ func (j *JWT) Apply(next web.HandlerFunc) web.HandlerFunc {
 	}
 }
 
+// Verify parses token and returns the user it identifies.
+func (j *JWT) Verify(ts string) (web.User, error) {
+	token, err := jwt.Parse(ts, j.KeyFunc)
+	if err != nil {
+		return nil, err
+	}
+	return j.Identifier(token), nil
+}
+
 func (j *JWT) extractToken(ctx web.Context) (token string) {
 	for _, src := range j.Sources {
 		switch src.Name {
@@ -48,15 +48,53 @@ func NewClient(opts ...Option) *Client {
 
 // Execute calls Skynet to dispatch task
 func (c *Client) Execute(param contract.ExecuteParam) error {
-	return c.do("/api/task/execute", param)
+	return c.do("/api/task/execute", param, nil)
+}
+
+// Submit calls Skynet to dispatch task immediately or at the time specified by param.At/param.Delay,
+// it returns id of the created job.
+func (c *Client) Submit(param contract.ExecuteParam) (string, error) {
+	r := &contract.ExecuteResult{}
+	if err := c.do("/api/task/execute", param, r); err != nil {
+		return "", err
+	}
+	return r.Id, nil
+}
+
+// Cancel calls Skynet to cancel job, a delayed job is cancelled before it is dispatched
+func (c *Client) Cancel(id string) error {
+	return c.do("/api/job/cancel", contract.CancelParam{Id: id}, nil)
 }
 
 // Notify sends execute result of job to Skynet
 func (c *Client) Notify(param contract.NotifyParam) error {
-	return c.do("/api/task/notify", param)
+	return c.do("/api/task/notify", param, nil)
+}
+
+// Heartbeat reports job is still running to Skynet
+func (c *Client) Heartbeat(param contract.HeartbeatParam) error {
+	return c.do("/api/task/heartbeat", param, nil)
+}
+
+// Poll waits for a job of param.Group until param.Wait is out, it returns nil if there is no job.
+// The job must be acked after it is finished, otherwise it is delivered again after visibility timeout.
+func (c *Client) Poll(param contract.PullParam) (*contract.PulledJob, error) {
+	job := &contract.PulledJob{}
+	if err := c.do("/api/runner/poll", param, job); err != nil {
+		return nil, err
+	} else if job.Id == "" {
+		return nil, nil
+	}
+	return job, nil
+}
+
+// Ack tells Skynet that a pulled job is finished
+func (c *Client) Ack(param contract.AckParam) error {
+	return c.do("/api/runner/ack", param, nil)
 }
 
-func (c *Client) do(path string, args interface{}) error {
+// do posts args to path, data of result is decoded into data if it is not nil.
+func (c *Client) do(path string, args, data interface{}) error {
 	b, err := json.Marshal(args)
 	if err != nil {
 		return err
@@ -83,7 +121,10 @@ func (c *Client) do(path string, args interface{}) error {
 	}
 
 	d := json.NewDecoder(resp.Body)
-	result := contract.Result{}
+	result := struct {
+		contract.Result
+		Data interface{} `json:"data,omitempty"`
+	}{Data: data}
 	err = d.Decode(&result)
 	if err != nil {
 		return err
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/cuigh/auxo/app"
+	"github.com/cuigh/auxo/app/flag"
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/runner"
+)
+
+func main() {
+	app.Name = "skynet-agent"
+	app.Version = "0.2"
+	app.Desc = "An agent runs shell commands for Skynet"
+	app.Flags.Register(flag.All)
+	app.Action = entry
+	app.Start()
+}
+
+func entry(ctx *app.Context) error {
+	// only commands in `agent.commands` can be executed, the handler of task is the name of command
+	commands := make(map[string]*runner.Command)
+	if err := config.UnmarshalOption("agent.commands", &commands); err != nil {
+		return errors.Wrap(err, "failed to load commands")
+	}
+	for name, cmd := range commands {
+		h, err := runner.NewCommandHandler(cmd)
+		if err != nil {
+			return errors.Wrap(err, "invalid command '%s'", name)
+		}
+		runner.Register(name, h)
+		log.Get("agent").Infof("command '%s' is allowed: %s %v", name, cmd.Path, cmd.Args)
+	}
+
+	// runner of tasks is pull://{group} if `agent.pull` is set, otherwise it is exec://host:port
+	if group := config.GetString("agent.pull"); group != "" {
+		return runner.ServePull(group)(ctx)
+	}
+	// agent runs commands for anyone who can reach it, so requests must be signed by scheduler
+	if config.GetString("skynet.runner.secret") == "" {
+		return errors.New("skynet.runner.secret is required, it must be the same as skynet.http.secret of scheduler")
+	}
+	return runner.Serve(web.Auto())(ctx)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEntryRequiresSecret makes sure agent refuses to serve commands to unsigned requests.
+func TestEntryRequiresSecret(t *testing.T) {
+	err := entry(nil)
+	if err == nil || !strings.Contains(err.Error(), "skynet.runner.secret") {
+		t.Fatalf("expected error for missing secret, got %v", err)
+	}
+}
@@ -12,7 +12,8 @@ const (
 	CodeFailed
 	CodeNotFound
 	CodeNotSupported
-	CodeTaskIsRunning
+	CodeTaskIsRunning // deprecated, overlap of jobs is controlled by scheduler now
+	CodeCancelled
 )
 
 type Result struct {
@@ -27,6 +28,7 @@ type Job struct {
 	Args    data.Options `json:"args"`
 	Mode    int32        `json:"mode"` // 0-auto, 1-manual
 	Fire    int64        `json:"fire"` // unix milliseconds
+	Attempt int32        `json:"attempt,omitempty"`
 }
 
 func (j *Job) String() string {
@@ -35,25 +37,61 @@ func (j *Job) String() string {
 }
 
 type ExecuteParam struct {
-	Name string       `json:"name"`
-	Args data.Options `json:"args,omitempty"`
+	Name  string       `json:"name"`
+	Args  data.Options `json:"args,omitempty"`
+	At    int64        `json:"at,omitempty"`    // unix milliseconds, job is dispatched at this time if it is in future
+	Delay int32        `json:"delay,omitempty"` // seconds, job is dispatched after delay, ignored if At is set
+}
+
+type ExecuteResult struct {
+	Id string `json:"id"` // id of created job
 }
 
 type NotifyParam struct {
-	Code  int32  `json:"code"`
-	Info  string `json:"info,omitempty"`
-	Id    string `json:"id,omitempty"`
-	Start int64  `json:"start,omitempty"` // unix milliseconds
-	End   int64  `json:"end,omitempty"`   // unix milliseconds
+	Code    int32  `json:"code"`
+	Info    string `json:"info,omitempty"`
+	Id      string `json:"id,omitempty"`
+	Attempt int32  `json:"attempt,omitempty"`
+	Start   int64  `json:"start,omitempty"` // unix milliseconds
+	End     int64  `json:"end,omitempty"`   // unix milliseconds
+}
+
+type HeartbeatParam struct {
+	Id     string `json:"id"`
+	Runner string `json:"runner,omitempty"` // instance of runner which is executing the job
+}
+
+// PullParam is used by runners in pull mode to take jobs of group.
+type PullParam struct {
+	Group    string   `json:"group"`
+	Handlers []string `json:"handlers,omitempty"` // handlers which runner can handle, all jobs of group are pulled if empty
+	Runner   string   `json:"runner,omitempty"`   // instance of runner
+	Wait     int32    `json:"wait,omitempty"`     // seconds, max time to wait for a job, server limits it to 30s
+}
+
+// PulledJob is a job delivered to runner, runner must ack it with Receipt after it is finished,
+// otherwise it is delivered again after visibility timeout.
+type PulledJob struct {
+	Job
+	Receipt string `json:"receipt"`
+}
+
+type AckParam struct {
+	Id      string `json:"id"`
+	Receipt string `json:"receipt"`
+}
+
+type CancelParam struct {
+	Id string `json:"id"`
 }
 
 type SplitResult struct {
-	Code    int32  `json:"code"` // 0-成功, 1-失败, 2-不支持拆分
-	Info    string `json:"info,omitempty"`
-	Batches []*Batch
+	Code    int32    `json:"code"` // 0-成功, 1-失败, 2-处理器不存在, 3-不支持拆分
+	Info    string   `json:"info,omitempty"`
+	Batches []*Batch `json:"batches,omitempty"`
 }
 
 type Batch struct {
-	Id   string
-	Args data.Options
+	Id   string       `json:"id"`
+	Args data.Options `json:"args,omitempty"`
 }
@@ -0,0 +1,82 @@
+package pb
+
+import (
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/skynet/contract"
+)
+
+func NewJob(j *contract.Job) *Job {
+	return &Job{
+		Id:      j.Id,
+		Task:    j.Task,
+		Handler: j.Handler,
+		Args:    newArgs(j.Args),
+		Mode:    j.Mode,
+		Fire:    j.Fire,
+		Attempt: j.Attempt,
+	}
+}
+
+func (j *Job) Contract() *contract.Job {
+	return &contract.Job{
+		Id:      j.Id,
+		Task:    j.Task,
+		Handler: j.Handler,
+		Args:    options(j.Args),
+		Mode:    j.Mode,
+		Fire:    j.Fire,
+		Attempt: j.Attempt,
+	}
+}
+
+func NewResult(r *contract.Result) *Result {
+	return &Result{Code: r.Code, Info: r.Info}
+}
+
+func NewSplitResult(r *contract.SplitResult) *SplitResult {
+	sr := &SplitResult{Code: r.Code, Info: r.Info}
+	for _, b := range r.Batches {
+		sr.Batches = append(sr.Batches, &Batch{Id: b.Id, Args: newArgs(b.Args)})
+	}
+	return sr
+}
+
+func (r *SplitResult) Contract() *contract.SplitResult {
+	sr := &contract.SplitResult{Code: r.Code, Info: r.Info}
+	for _, b := range r.Batches {
+		sr.Batches = append(sr.Batches, &contract.Batch{Id: b.Id, Args: options(b.Args)})
+	}
+	return sr
+}
+
+func newArgs(opts data.Options) []*Arg {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	args := make([]*Arg, len(opts))
+	for i, opt := range opts {
+		args[i] = &Arg{Name: opt.Name, Value: opt.Value}
+	}
+	return args
+}
+
+func options(args []*Arg) data.Options {
+	if len(args) == 0 {
+		return nil
+	}
+
+	opts := make(data.Options, len(args))
+	for i, arg := range args {
+		opts[i] = data.Option{Name: arg.Name, Value: arg.Value}
+	}
+	return opts
+}
+
+func NewNotifyParam(p contract.NotifyParam) *NotifyParam {
+	return &NotifyParam{Code: p.Code, Info: p.Info, Id: p.Id, Attempt: p.Attempt, Start: p.Start, End: p.End}
+}
+
+func NewHeartbeatParam(p contract.HeartbeatParam) *HeartbeatParam {
+	return &HeartbeatParam{Id: p.Id, Runner: p.Runner}
+}
@@ -0,0 +1,15 @@
+package pb
+
+import "context"
+
+// Token sends `authorization: Bearer <token>` with every RPC, it should be used with TLS to keep token secret.
+type Token string
+
+func (t Token) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity returns false, so token can also be used by insecure connections in trusted networks.
+func (t Token) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,34 @@
+package contract
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers of signed requests sent by scheduler to HTTP runners.
+const (
+	HeaderTimestamp = "X-Skynet-Timestamp" // unix seconds when request was signed
+	HeaderNonce     = "X-Skynet-Nonce"     // random string which is unique for each request
+	HeaderSignature = "X-Skynet-Signature" // hex encoded HMAC-SHA256 of "{timestamp}\n{nonce}\n{method}\n{path}\n{body}"
+)
+
+// Sign returns signature of request signed at ts(unix seconds) with secret. Method and path are signed too, so a
+// signed request can't be replayed to another API. Host is not signed because proxies often rewrite it.
+func Sign(secret string, ts int64, nonce, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "\n" + nonce + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches request signed at ts with secret.
+func Verify(secret string, ts int64, nonce, method, path string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	actual, _ := hex.DecodeString(Sign(secret, ts, nonce, method, path, body))
+	return hmac.Equal(expected, actual)
+}
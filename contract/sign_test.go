@@ -0,0 +1,37 @@
+package contract
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", 1700000000, "nonce", "POST", "/task/execute", body)
+
+	cases := []struct {
+		name      string
+		secret    string
+		ts        int64
+		nonce     string
+		method    string
+		path      string
+		body      string
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", 1700000000, "nonce", "POST", "/task/execute", `{"id":"1"}`, signature, true},
+		{"wrong secret", "other", 1700000000, "nonce", "POST", "/task/execute", `{"id":"1"}`, signature, false},
+		{"wrong timestamp", "secret", 1700000001, "nonce", "POST", "/task/execute", `{"id":"1"}`, signature, false},
+		{"wrong nonce", "secret", 1700000000, "other", "POST", "/task/execute", `{"id":"1"}`, signature, false},
+		{"wrong method", "secret", 1700000000, "nonce", "PUT", "/task/execute", `{"id":"1"}`, signature, false},
+		{"wrong path", "secret", 1700000000, "nonce", "POST", "/task/cancel", `{"id":"1"}`, signature, false},
+		{"wrong body", "secret", 1700000000, "nonce", "POST", "/task/execute", `{"id":"2"}`, signature, false},
+		{"not hex", "secret", 1700000000, "nonce", "POST", "/task/execute", `{"id":"1"}`, "not hex", false},
+		{"empty", "secret", 1700000000, "nonce", "POST", "/task/execute", `{"id":"1"}`, "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Verify(c.secret, c.ts, c.nonce, c.method, c.path, []byte(c.body), c.signature); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"github.com/cuigh/auxo/app"
 	"github.com/cuigh/auxo/app/flag"
@@ -9,15 +10,20 @@ import (
 	"github.com/cuigh/auxo/data"
 	"github.com/cuigh/auxo/data/valid"
 	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/log"
 	"github.com/cuigh/auxo/net/web"
 	"github.com/cuigh/auxo/net/web/filter"
-	_ "github.com/cuigh/skynet/api"
+	"github.com/cuigh/skynet/api"
+	"github.com/cuigh/skynet/auth"
 	"github.com/cuigh/skynet/contract"
 	_ "github.com/cuigh/skynet/lock"
 	"github.com/cuigh/skynet/runner"
 	"github.com/cuigh/skynet/schedule"
 	_ "github.com/cuigh/skynet/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"io/fs"
+	"net"
 	"net/http"
 	"time"
 )
@@ -38,15 +44,55 @@ func main() {
 
 func entry(*app.Context) error {
 	// 启动调度器
+	var scheduler *schedule.Scheduler
 	app.Ensure(ioc.Call(func(s *schedule.Scheduler) {
+		scheduler = s
 		go s.Start()
 	}))
 
+	// 启动 gRPC 服务
+	gs := createGRPCServer(scheduler)
+
 	// 启动网站
-	app.Run(createWebServer())
+	ws := createWebServer()
+	app.RunFunc(ws.Serve, func(timeout time.Duration) {
+		// stop scheduler first, runners may still report results of in-flight jobs
+		scheduler.Stop(timeout)
+		if gs != nil {
+			runner.StopGRPC(gs, timeout)
+		}
+		ws.Close(timeout)
+	})
 	return nil
 }
 
+// createGRPCServer starts gRPC server for runners if `skynet.grpc.address` is set, it serves with TLS
+// if `skynet.grpc.cert` and `skynet.grpc.key` are set.
+func createGRPCServer(s *schedule.Scheduler) *grpc.Server {
+	addr := config.GetString("skynet.grpc.address")
+	if addr == "" {
+		return nil
+	}
+
+	var opts []grpc.ServerOption
+	if cert, key := config.GetString("skynet.grpc.cert"), config.GetString("skynet.grpc.key"); cert != "" {
+		creds, err := credentials.NewServerTLSFromFile(cert, key)
+		app.Ensure(err)
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	l, err := net.Listen("tcp", addr)
+	app.Ensure(err)
+
+	gs := api.NewGRPCServer(s, ioc.Find[*auth.JWT]("authenticator"), opts...)
+	go func() {
+		if err := gs.Serve(l); err != nil {
+			log.Get("grpc").Error("gRPC server stopped: ", err)
+		}
+	}()
+	return gs
+}
+
 func createWebServer() *web.Server {
 	ws := web.Auto()
 	ws.Validator = &valid.Validator{}
@@ -58,13 +104,18 @@ func createWebServer() *web.Server {
 	g.Handle("/system", ioc.Find[any]("api.system"))
 	g.Handle("/task", ioc.Find[any]("api.task"))
 	g.Handle("/job", ioc.Find[any]("api.job"))
+	g.Handle("/workflow", ioc.Find[any]("api.workflow"))
+	g.Handle("/calendar", ioc.Find[any]("api.calendar"))
 	g.Handle("/user", ioc.Find[any]("api.user"))
 	g.Handle("/role", ioc.Find[any]("api.role"))
 	g.Handle("/config", ioc.Find[any]("api.config"))
+	g.Handle("/runner", ioc.Find[any]("api.runner"))
 
-	// runner testing
-	ws.Post("/task/execute", runner.HandleExecute, web.WithAuthorize(web.AuthAnonymous))
-	ws.Post("/task/split", runner.HandleSplit, web.WithAuthorize(web.AuthAnonymous))
+	// runner testing, tasks can also run handlers registered in this process by runner `local://` without HTTP
+	rs := runner.NewHTTPServer(nil)
+	ws.Post("/task/execute", rs.HandleExecute, web.WithAuthorize(web.AuthAnonymous))
+	ws.Post("/task/split", rs.HandleSplit, web.WithAuthorize(web.AuthAnonymous))
+	ws.Post("/task/cancel", rs.HandleCancel, web.WithAuthorize(web.AuthAnonymous))
 
 	return ws
 }
@@ -108,8 +159,11 @@ func init() {
 	// runner testing
 	config.SetDefaultValue("skynet.address", "http://localhost:8001")
 	//config.SetDefaultValue("skynet.token", "")
-	runner.RegisterFunc("Test", func(job *contract.Job) error {
-		time.Sleep(time.Second * 3)
+	runner.RegisterContextFunc("Test", func(ctx context.Context, job *contract.Job) error {
+		select {
+		case <-time.After(time.Second * 3):
+		case <-ctx.Done():
+		}
 		return nil
 	})
 }
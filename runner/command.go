@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/skynet/contract"
+)
+
+const defaultOutputLimit = 4096
+
+var envName = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// Command is an allowed command which can be executed by jobs, jobs can't change the command itself,
+// their args are passed as environment variables like SKYNET_ARG_{NAME}.
+type Command struct {
+	Path        string        `option:"path"` // executable file
+	Args        []string      `option:"args"`
+	Dir         string        `option:"dir"`
+	Env         []string      `option:"env"` // extra environment variables, e.g. KEY=value
+	Timeout     time.Duration `option:"timeout"`
+	OutputLimit int           `option:"output_limit"` // max bytes kept of stdout and stderr each, default is 4096
+}
+
+// CommandHandler runs a Command for jobs, exit code, output and duration of command are reported as result info.
+type CommandHandler struct {
+	cmd *Command
+}
+
+func NewCommandHandler(cmd *Command) (*CommandHandler, error) {
+	if cmd.Path == "" {
+		return nil, errors.New("path of command is required")
+	}
+	if cmd.OutputLimit <= 0 {
+		cmd.OutputLimit = defaultOutputLimit
+	}
+	return &CommandHandler{cmd: cmd}, nil
+}
+
+func (h *CommandHandler) Handle(job *contract.Job) error {
+	_, err := h.HandleInfo(context.Background(), job)
+	return err
+}
+
+func (h *CommandHandler) HandleContext(ctx context.Context, job *contract.Job) error {
+	_, err := h.HandleInfo(ctx, job)
+	return err
+}
+
+func (h *CommandHandler) HandleInfo(ctx context.Context, job *contract.Job) (string, error) {
+	if h.cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.cmd.Timeout)
+		defer cancel()
+	}
+
+	stdout, stderr := &tailBuffer{limit: h.cmd.OutputLimit}, &tailBuffer{limit: h.cmd.OutputLimit}
+	c := exec.CommandContext(ctx, h.cmd.Path, h.cmd.Args...)
+	c.Dir = h.cmd.Dir
+	c.Env = append(append(os.Environ(), h.cmd.Env...), jobEnv(job)...)
+	c.Stdout, c.Stderr = stdout, stderr
+
+	start := time.Now()
+	err := c.Run()
+	duration := time.Since(start)
+
+	code := -1
+	if c.ProcessState != nil {
+		code = c.ProcessState.ExitCode()
+	}
+	info := fmt.Sprintf("exit code: %d, duration: %s", code, duration.Round(time.Millisecond))
+	if s := stdout.String(); s != "" {
+		info += "\nstdout:\n" + s
+	}
+	if s := stderr.String(); s != "" {
+		info += "\nstderr:\n" + s
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return info, errors.New("command timed out, " + info)
+	} else if err != nil {
+		return info, errors.New(err.Error() + ", " + info)
+	}
+	return info, nil
+}
+
+// jobEnv returns environment variables of job.
+func jobEnv(job *contract.Job) []string {
+	env := []string{
+		"SKYNET_JOB_ID=" + job.Id,
+		"SKYNET_TASK=" + job.Task,
+		"SKYNET_HANDLER=" + job.Handler,
+		"SKYNET_MODE=" + strconv.Itoa(int(job.Mode)),
+		"SKYNET_FIRE=" + strconv.FormatInt(job.Fire, 10),
+		"SKYNET_ATTEMPT=" + strconv.Itoa(int(job.Attempt)),
+	}
+	for _, arg := range job.Args {
+		name := envName.ReplaceAllString(strings.ToUpper(arg.Name), "_")
+		env = append(env, "SKYNET_ARG_"+name+"="+arg.Value)
+	}
+	return env
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if n := len(b.buf) - b.limit; n > 0 {
+		b.buf = append(b.buf[:0], b.buf[n:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	if b.truncated {
+		return "...(truncated)" + string(b.buf)
+	}
+	return string(b.buf)
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/skynet/contract"
+)
+
+func TestCommandHandler(t *testing.T) {
+	if _, err := NewCommandHandler(&Command{}); err == nil {
+		t.Fatal("expected error for command without path")
+	}
+
+	cases := []struct {
+		name    string
+		cmd     *Command
+		ok      bool
+		contain []string
+	}{
+		{
+			"env and output",
+			&Command{Path: "/bin/sh", Args: []string{"-c", `echo "$SKYNET_TASK:$SKYNET_ARG_FOO_BAR:$EXTRA"; echo oops >&2`}, Env: []string{"EXTRA=1"}},
+			true,
+			[]string{"exit code: 0", "stdout:\ntest:baz:1\n", "stderr:\noops\n"},
+		},
+		{
+			"exit code",
+			&Command{Path: "/bin/sh", Args: []string{"-c", "exit 3"}},
+			false,
+			[]string{"exit code: 3"},
+		},
+		{
+			"timeout",
+			&Command{Path: "/bin/sh", Args: []string{"-c", "sleep 5"}, Timeout: 100 * time.Millisecond},
+			false,
+			[]string{"command timed out"},
+		},
+		{
+			"output limit",
+			&Command{Path: "/bin/sh", Args: []string{"-c", "echo 0123456789"}, OutputLimit: 4},
+			true,
+			[]string{"stdout:\n...(truncated)789\n"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h, err := NewCommandHandler(c.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			job := &contract.Job{Id: "1", Task: "test", Handler: "cmd", Args: data.Options{{Name: "foo-bar", Value: "baz"}}}
+			info, err := h.HandleInfo(context.Background(), job)
+			if (err == nil) != c.ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				info = err.Error()
+			}
+			for _, s := range c.contain {
+				if !strings.Contains(info, s) {
+					t.Fatalf("info doesn't contain %q: %s", s, info)
+				}
+			}
+		})
+	}
+}
+
+// TestHandleRejectsUnknownCommand makes sure that only registered commands can be executed by agent.
+func TestHandleRejectsUnknownCommand(t *testing.T) {
+	rec := newRecorder()
+	Execute(&contract.Job{Id: "unknown", Task: "test", Handler: "rm", Args: data.Options{{Name: "path", Value: "/"}}}, rec)
+	if r := rec.wait(t); r.Id != "unknown" || r.Code != contract.CodeNotFound {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
@@ -0,0 +1,174 @@
+package runner
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cuigh/auxo/app"
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/contract/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// reportTimeout limits every report to Skynet by gRPC.
+const reportTimeout = 10 * time.Second
+
+// grpcServer serves handlers registered in this package for scheduler by gRPC.
+type grpcServer struct {
+	pb.UnimplementedRunnerServer
+	r Reporter
+}
+
+func (s grpcServer) Execute(_ context.Context, job *pb.Job) (*pb.Result, error) {
+	return pb.NewResult(Execute(job.Contract(), s.r)), nil
+}
+
+func (grpcServer) Split(_ context.Context, job *pb.Job) (*pb.SplitResult, error) {
+	return pb.NewSplitResult(Split(job.Contract())), nil
+}
+
+func (grpcServer) Cancel(_ context.Context, param *pb.CancelParam) (*pb.Result, error) {
+	return pb.NewResult(Cancel(param.Id)), nil
+}
+
+// grpcReporter reports to pb.SchedulerServer of Skynet.
+type grpcReporter struct {
+	client pb.SchedulerClient
+}
+
+// newGRPCReporter connects to Skynet at `skynet.grpc.address`(grpc://host:port or grpcs://host:port) with token
+// `skynet.token`, TLS connection is verified by `skynet.grpc.ca` and `skynet.grpc.insecure`.
+func newGRPCReporter(addr string) (*grpcReporter, error) {
+	target, creds := strings.TrimPrefix(addr, "grpc://"), insecure.NewCredentials()
+	if strings.HasPrefix(addr, "grpcs://") {
+		cfg, err := contract.LoadTLS(config.GetString("skynet.grpc.ca"), "", "", config.GetBool("skynet.grpc.insecure"))
+		if err != nil {
+			return nil, err
+		}
+		target, creds = strings.TrimPrefix(addr, "grpcs://"), credentials.NewTLS(cfg)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if token := config.GetString("skynet.token"); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(pb.Token(token)))
+	}
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcReporter{client: pb.NewSchedulerClient(conn)}, nil
+}
+
+func (r *grpcReporter) Notify(param contract.NotifyParam) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	result, err := r.client.Notify(ctx, pb.NewNotifyParam(param))
+	return reportError(result, err)
+}
+
+func (r *grpcReporter) Heartbeat(param contract.HeartbeatParam) error {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	result, err := r.client.Heartbeat(ctx, pb.NewHeartbeatParam(param))
+	return reportError(result, err)
+}
+
+func reportError(result *pb.Result, err error) error {
+	if err != nil {
+		return err
+	} else if result.Code != contract.CodeSuccess {
+		return errors.Coded(result.Code, result.Info)
+	}
+	return nil
+}
+
+// NewGRPCServer creates a gRPC server which serves registered handlers, it can be shared with other services.
+// Results are reported by gRPC if `skynet.grpc.address` is set, otherwise by HTTP API at `skynet.address`.
+// If `skynet.runner.token` is set, scheduler must send the same token as `skynet.grpc.runner.token`.
+// It panics if options are invalid.
+func NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	var r Reporter = remote{}
+	if addr := config.GetString("skynet.grpc.address"); addr != "" {
+		gr, err := newGRPCReporter(addr)
+		if err != nil {
+			panic(errors.Wrap(err, "failed to connect to Skynet by gRPC"))
+		}
+		r = gr
+	}
+	if token := config.GetString("skynet.runner.token"); token != "" {
+		opts = append(opts, grpc.ChainUnaryInterceptor(authenticate(token)))
+	}
+
+	s := grpc.NewServer(opts...)
+	pb.RegisterRunnerServer(s, grpcServer{r: r})
+	return s
+}
+
+// authenticate checks token in metadata `authorization: Bearer <token>`.
+func authenticate(token string) grpc.UnaryServerInterceptor {
+	expected := []byte("Bearer " + token)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || subtle.ConstantTimeCompare([]byte(values[0]), expected) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ServeGRPC is like Serve, but handlers are served by gRPC on addr, runner of tasks should be grpc://host:port.
+// If `skynet.runner.cert` and `skynet.runner.key` are set, it serves with TLS and runner of tasks should be
+// grpcs://host:port.
+func ServeGRPC(addr string) func(ctx *app.Context) error {
+	return func(ctx *app.Context) error {
+		var opts []grpc.ServerOption
+		if cert, key := config.GetString("skynet.runner.cert"), config.GetString("skynet.runner.key"); cert != "" {
+			creds, err := credentials.NewServerTLSFromFile(cert, key)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		s := NewGRPCServer(opts...)
+		app.RunFunc(func() error {
+			return s.Serve(l)
+		}, func(timeout time.Duration) {
+			StopGRPC(s, timeout)
+		})
+		return nil
+	}
+}
+
+// StopGRPC stops server gracefully, pending RPCs are aborted if they are not finished before timeout.
+func StopGRPC(s *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.Stop()
+	}
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticate(t *testing.T) {
+	interceptor := authenticate("secret")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	cases := []struct {
+		name string
+		md   metadata.MD
+		ok   bool
+	}{
+		{"valid", metadata.Pairs("authorization", "Bearer secret"), true},
+		{"missing", nil, false},
+		{"wrong token", metadata.Pairs("authorization", "Bearer other"), false},
+		{"no bearer", metadata.Pairs("authorization", "secret"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			if c.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, c.md)
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if c.ok && err != nil {
+				t.Fatal(err)
+			} else if !c.ok && status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("expected Unauthenticated, got %v", err)
+			}
+		})
+	}
+}
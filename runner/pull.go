@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cuigh/auxo/app"
+	"github.com/cuigh/auxo/app/ioc"
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/client"
+	"github.com/cuigh/skynet/contract"
+)
+
+// puller pulls jobs of a group from Skynet and runs them with registered handlers.
+type puller struct {
+	group  string
+	slots  chan struct{} // limits jobs running at the same time
+	closer chan struct{}
+	wg     sync.WaitGroup
+	logger log.Logger
+}
+
+// ServePull is like Serve, but runner pulls jobs from Skynet instead of serving requests, so it can run behind NAT or
+// firewall. Runner of tasks should be pull://{group}, max running jobs can be set by config `skynet.pull.concurrency`.
+func ServePull(group string) func(ctx *app.Context) error {
+	return func(ctx *app.Context) error {
+		concurrency := config.GetInt("skynet.pull.concurrency")
+		if concurrency <= 0 {
+			concurrency = 10
+		}
+
+		p := &puller{
+			group:  group,
+			slots:  make(chan struct{}, concurrency),
+			closer: make(chan struct{}),
+			logger: log.Get("task"),
+		}
+		app.RunFunc(p.run, p.stop)
+		return nil
+	}
+}
+
+func (p *puller) run() error {
+	param := contract.PullParam{Group: p.group, Runner: Instance()}
+	for name := range handlers {
+		param.Handlers = append(param.Handlers, name)
+	}
+
+	for {
+		// take a slot before polling, so that a pulled job can start immediately
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.closer:
+			return nil
+		}
+		// a job pulled during stopping is leased to this runner already, so stop waits for pending poll too
+		p.wg.Add(1)
+
+		var job *contract.PulledJob
+		err := ioc.Call(func(client *client.Client) (err error) {
+			job, err = client.Poll(param)
+			return
+		})
+		if err != nil {
+			p.logger.Errorf("failed to pull jobs of group '%s': %s", p.group, err)
+			p.release()
+			select {
+			case <-time.After(5 * time.Second):
+				continue
+			case <-p.closer:
+				return nil
+			}
+		} else if job == nil {
+			p.release()
+			select {
+			case <-p.closer:
+				return nil
+			default:
+				continue
+			}
+		}
+
+		go p.execute(job)
+	}
+}
+
+func (p *puller) execute(job *contract.PulledJob) {
+	defer p.release()
+
+	handle(&job.Job, remote{})
+	err := ioc.Call(func(client *client.Client) error {
+		return client.Ack(contract.AckParam{Id: job.Id, Receipt: job.Receipt})
+	})
+	if err != nil {
+		p.logger.Errorf("failed to ack job(%s): %s", job.Id, err)
+	}
+}
+
+func (p *puller) release() {
+	<-p.slots
+	p.wg.Done()
+}
+
+// stop stops pulling and waits for running jobs until timeout, unfinished jobs are delivered again to other runners.
+func (p *puller) stop(timeout time.Duration) {
+	close(p.closer)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		p.logger.Warn("runner stopped with pulled jobs unfinished")
+	}
+}
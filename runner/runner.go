@@ -1,12 +1,15 @@
 package runner
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/cuigh/auxo/app"
 	"github.com/cuigh/auxo/app/ioc"
+	"github.com/cuigh/auxo/config"
 	"github.com/cuigh/auxo/ext/times"
 	"github.com/cuigh/auxo/log"
 	"github.com/cuigh/auxo/net/web"
@@ -16,10 +19,31 @@ import (
 )
 
 var (
-	handlers = make(map[string]Handler)
-	running  = sync.Map{}
+	handlers   = make(map[string]Handler)
+	executions = sync.Map{} // job id -> context.CancelFunc, it is nil if handler is not a ContextHandler
 )
 
+// Reporter sends results and heartbeats of jobs to Skynet.
+type Reporter interface {
+	Notify(param contract.NotifyParam) error
+	Heartbeat(param contract.HeartbeatParam) error
+}
+
+// remote reports to Skynet by client.Client registered in ioc container.
+type remote struct{}
+
+func (remote) Notify(param contract.NotifyParam) error {
+	return ioc.Call(func(client *client.Client) error {
+		return client.Notify(param)
+	})
+}
+
+func (remote) Heartbeat(param contract.HeartbeatParam) error {
+	return ioc.Call(func(client *client.Client) error {
+		return client.Heartbeat(param)
+	})
+}
+
 type PreFilter func(job *contract.Job) error
 
 type PostFilter func(job *contract.Job, err error)
@@ -28,6 +52,19 @@ type Handler interface {
 	Handle(job *contract.Job) error
 }
 
+// ContextHandler is a Handler which can be cancelled, ctx is done when the job is cancelled.
+type ContextHandler interface {
+	Handler
+	HandleContext(ctx context.Context, job *contract.Job) error
+}
+
+// InfoHandler is a ContextHandler which reports information of execution, e.g. output of a command.
+// The info is reported as result of job even if execution succeeded.
+type InfoHandler interface {
+	ContextHandler
+	HandleInfo(ctx context.Context, job *contract.Job) (info string, err error)
+}
+
 type ParallelHandler interface {
 	Handler
 	Split(job *contract.Job) ([]*contract.Batch, error)
@@ -42,6 +79,16 @@ func (f HandlerFunc) Handle(job *contract.Job) error {
 	return f(job)
 }
 
+type ContextHandlerFunc func(ctx context.Context, job *contract.Job) error
+
+func (f ContextHandlerFunc) Handle(job *contract.Job) error {
+	return f(context.Background(), job)
+}
+
+func (f ContextHandlerFunc) HandleContext(ctx context.Context, job *contract.Job) error {
+	return f(ctx, job)
+}
+
 func Register(name string, handler Handler) {
 	handlers[name] = handler
 }
@@ -50,87 +97,193 @@ func RegisterFunc(name string, handler func(job *contract.Job) error) {
 	handlers[name] = HandlerFunc(handler)
 }
 
+func RegisterContextFunc(name string, handler func(ctx context.Context, job *contract.Job) error) {
+	handlers[name] = ContextHandlerFunc(handler)
+}
+
+// Serve registers HTTP handlers of runner to ws, replays of signed requests are detected in memory of this instance.
 func Serve(ws *web.Server) func(ctx *app.Context) error {
-	ws.Post("/task/execute", HandleExecute)
-	ws.Post("/task/split", HandleSplit)
+	s := NewHTTPServer(nil)
+	ws.Post("/task/execute", s.HandleExecute)
+	ws.Post("/task/split", s.HandleSplit)
+	ws.Post("/task/cancel", s.HandleCancel)
 	return func(ctx *app.Context) error {
 		app.Run(ws)
 		return nil
 	}
 }
 
-func HandleExecute(ctx web.Context) error {
+// HTTPServer handles HTTP requests of scheduler.
+type HTTPServer struct {
+	nonces NonceStore
+}
+
+// NewHTTPServer creates HTTPServer which checks replays of signed requests with nonces,
+// an in-memory store is used if nonces is nil.
+func NewHTTPServer(nonces NonceStore) *HTTPServer {
+	if nonces == nil {
+		nonces = NewMemoryNonceStore()
+	}
+	return &HTTPServer{nonces: nonces}
+}
+
+func (s *HTTPServer) HandleExecute(ctx web.Context) error {
 	var job contract.Job
-	err := ctx.Bind(&job)
+	err := s.bind(ctx, &job)
 	if err != nil {
 		return ctx.JSON(contract.Result{Code: contract.CodeFailed, Info: err.Error()})
 	}
 
-	go handle(&job)
-	return ctx.JSON(contract.Result{})
+	return ctx.JSON(Execute(&job, remote{}))
 }
 
-func HandleSplit(ctx web.Context) error {
+func (s *HTTPServer) HandleSplit(ctx web.Context) error {
 	var job contract.Job
-	err := ctx.Bind(&job)
+	err := s.bind(ctx, &job)
+	if err != nil {
+		return ctx.JSON(contract.Result{Code: contract.CodeFailed, Info: err.Error()})
+	}
+
+	return ctx.JSON(Split(&job))
+}
+
+func (s *HTTPServer) HandleCancel(ctx web.Context) error {
+	var param contract.CancelParam
+	err := s.bind(ctx, &param)
 	if err != nil {
 		return ctx.JSON(contract.Result{Code: contract.CodeFailed, Info: err.Error()})
 	}
 
-	result := split(&job)
-	return ctx.JSON(result)
+	return ctx.JSON(Cancel(param.Id))
+}
+
+// Execute runs job in background with registered handler, result and heartbeats of job are sent by r.
+// It's used by callers which invoke handlers in the same process directly.
+func Execute(job *contract.Job, r Reporter) *contract.Result {
+	go handle(job, r)
+	return &contract.Result{}
 }
 
-func handle(job *contract.Job) {
+func handle(job *contract.Job, r Reporter) {
 	log.Get("task").Debugf("handle job: %s", job)
 
 	start := time.Now()
 
 	handler := handlers[job.Handler]
 	if handler == nil {
-		notify(job, start, contract.CodeNotFound, "handler not found")
+		notify(r, job, start, contract.CodeNotFound, "handler not found")
 		return
 	}
 
-	if job.Mode == 0 {
-		if last, exist := running.LoadOrStore(job.Task, job); exist {
-			fire := times.FromUnixMilli(last.(*contract.Job).Fire)
-			notify(job, start, contract.CodeTaskIsRunning, fmt.Sprintf("task is already running(fire: %s)",
-				fire.Format("2006-01-02 15:04:05")))
-			return
-		}
-		defer running.Delete(job.Task)
-	}
+	stop := heartbeat(r, job)
+	defer stop()
+
+	executions.Store(job.Id, context.CancelFunc(nil))
+	defer executions.Delete(job.Id)
 
 	run.Safe(func() {
-		err := handler.Handle(job)
+		var (
+			err  error
+			info string
+		)
+		if h, ok := handler.(ContextHandler); ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			executions.Store(job.Id, cancel)
+
+			if ih, ok := h.(InfoHandler); ok {
+				info, err = ih.HandleInfo(ctx, job)
+			} else {
+				err = h.HandleContext(ctx, job)
+			}
+			if ctx.Err() != nil {
+				notify(r, job, start, contract.CodeCancelled, "job was cancelled")
+				return
+			}
+		} else {
+			err = handler.Handle(job)
+		}
+
 		if err != nil {
-			notify(job, start, contract.CodeFailed, err.Error())
+			notify(r, job, start, contract.CodeFailed, err.Error())
 		} else {
-			notify(job, start, contract.CodeSuccess, "")
+			notify(r, job, start, contract.CodeSuccess, info)
 		}
 	}, func(e interface{}) {
-		notify(job, start, contract.CodeFailed, fmt.Sprint(e))
+		notify(r, job, start, contract.CodeFailed, fmt.Sprint(e))
 	})
 }
 
-func notify(job *contract.Job, start time.Time, code int32, info string) {
+func notify(r Reporter, job *contract.Job, start time.Time, code int32, info string) {
 	param := contract.NotifyParam{
-		Code:  code,
-		Info:  info,
-		Id:    job.Id,
-		Start: times.ToUnixMilli(start),
-		End:   times.ToUnixMilli(time.Now()),
+		Code:    code,
+		Info:    info,
+		Id:      job.Id,
+		Attempt: job.Attempt,
+		Start:   times.ToUnixMilli(start),
+		End:     times.ToUnixMilli(time.Now()),
 	}
-	err := ioc.Call(func(client *client.Client) error {
-		return client.Notify(param)
-	})
-	if err != nil {
+	if err := r.Notify(param); err != nil {
 		log.Get("task").Errorf("failed to notify result of job(%s): %s", job.Id, err)
 	}
 }
 
-func split(job *contract.Job) *contract.SplitResult {
+// Cancel cancels a running job, only jobs handled by ContextHandler can be cancelled.
+func Cancel(id string) *contract.Result {
+	v, ok := executions.Load(id)
+	if !ok {
+		return &contract.Result{Code: contract.CodeNotFound, Info: "job is not running"}
+	}
+
+	cancel := v.(context.CancelFunc)
+	if cancel == nil {
+		return &contract.Result{Code: contract.CodeNotSupported, Info: "handler doesn't support cancellation"}
+	}
+
+	log.Get("task").Infof("cancel job: %s", id)
+	cancel()
+	return &contract.Result{}
+}
+
+// heartbeat reports job is running periodically until the returned function is called.
+func heartbeat(r Reporter, job *contract.Job) (stop func()) {
+	interval := config.GetDuration("skynet.heartbeat")
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	closer := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		param := contract.HeartbeatParam{Id: job.Id, Runner: Instance()}
+		for {
+			if err := r.Heartbeat(param); err != nil {
+				log.Get("task").Warnf("failed to send heartbeat of job(%s): %s", job.Id, err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-closer:
+				return
+			}
+		}
+	}()
+	return func() { close(closer) }
+}
+
+// Instance returns identity of current runner process, it can be set by config `skynet.instance`.
+func Instance() string {
+	if id := config.GetString("skynet.instance"); id != "" {
+		return id
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Split splits job into batches by registered ParallelHandler.
+func Split(job *contract.Job) *contract.SplitResult {
 	log.Get("task").Debugf("split job: %s", job)
 
 	h := handlers[job.Handler]
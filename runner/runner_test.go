@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/contract"
+)
+
+// recorder is a Reporter which records results of jobs.
+type recorder struct {
+	results chan contract.NotifyParam
+}
+
+func newRecorder() *recorder {
+	return &recorder{results: make(chan contract.NotifyParam, 10)}
+}
+
+func (r *recorder) Notify(param contract.NotifyParam) error {
+	r.results <- param
+	return nil
+}
+
+func (r *recorder) Heartbeat(param contract.HeartbeatParam) error {
+	return nil
+}
+
+// wait returns next result notified by runner.
+func (r *recorder) wait(t *testing.T) contract.NotifyParam {
+	t.Helper()
+
+	select {
+	case p := <-r.results:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("result is not notified")
+	}
+	return contract.NotifyParam{}
+}
+
+// waitExecution waits until job is registered as executing and returns its cancel function.
+func waitExecution(t *testing.T, id string) context.CancelFunc {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if v, ok := executions.Load(id); ok {
+			return v.(context.CancelFunc)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job '%s' is not executing", id)
+	return nil
+}
+
+func TestCancel(t *testing.T) {
+	started := make(chan struct{})
+	RegisterContextFunc("test.cancel", func(ctx context.Context, job *contract.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	t.Cleanup(func() { delete(handlers, "test.cancel") })
+
+	job := &contract.Job{Id: "cancel", Task: "test", Handler: "test.cancel", Mode: 1}
+	rec := newRecorder()
+	Execute(job, rec)
+	<-started
+	if waitExecution(t, job.Id) == nil {
+		t.Fatal("cancel function is not registered")
+	}
+
+	if r := Cancel(job.Id); r.Code != contract.CodeSuccess {
+		t.Fatalf("cancel failed: %s", r.Info)
+	}
+	if r := rec.wait(t); r.Code != contract.CodeCancelled {
+		t.Fatalf("expected CodeCancelled, got %d", r.Code)
+	}
+	if r := Cancel(job.Id); r.Code != contract.CodeNotFound {
+		t.Fatalf("expected CodeNotFound for finished job, got %d", r.Code)
+	}
+}
+
+func TestCancelNotSupported(t *testing.T) {
+	release := make(chan struct{})
+	RegisterFunc("test.block", func(job *contract.Job) error {
+		<-release
+		return nil
+	})
+	t.Cleanup(func() { delete(handlers, "test.block") })
+
+	job := &contract.Job{Id: "block", Task: "test", Handler: "test.block", Mode: 1}
+	rec := newRecorder()
+	Execute(job, rec)
+	waitExecution(t, job.Id)
+
+	if r := Cancel(job.Id); r.Code != contract.CodeNotSupported {
+		t.Fatalf("expected CodeNotSupported, got %d", r.Code)
+	}
+	close(release)
+	if r := rec.wait(t); r.Code != contract.CodeSuccess {
+		t.Fatalf("expected CodeSuccess, got %d", r.Code)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	RegisterFunc("test.ok", func(job *contract.Job) error { return nil })
+	RegisterFunc("test.fail", func(job *contract.Job) error { return errors.New("boom") })
+	RegisterFunc("test.panic", func(job *contract.Job) error { panic("oops") })
+	t.Cleanup(func() {
+		delete(handlers, "test.ok")
+		delete(handlers, "test.fail")
+		delete(handlers, "test.panic")
+	})
+
+	cases := []struct {
+		handler string
+		code    int32
+		info    string
+	}{
+		{"test.ok", contract.CodeSuccess, ""},
+		{"test.fail", contract.CodeFailed, "boom"},
+		{"test.panic", contract.CodeFailed, "oops"},
+		{"test.missing", contract.CodeNotFound, "handler not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.handler, func(t *testing.T) {
+			rec := newRecorder()
+			job := &contract.Job{Id: c.handler, Task: "test", Handler: c.handler, Attempt: 2}
+			if r := Execute(job, rec); r.Code != contract.CodeSuccess {
+				t.Fatalf("execute failed: %s", r.Info)
+			}
+
+			r := rec.wait(t)
+			if r.Id != job.Id || r.Attempt != job.Attempt || r.Code != c.code || r.Info != c.info {
+				t.Fatalf("unexpected result: %+v", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/contract"
+)
+
+const maxBodySize = 4 << 20
+
+// NonceStore records nonces of verified requests, so a signed request can't be replayed.
+type NonceStore interface {
+	// Add records nonce until exp, it returns false if nonce was recorded already and isn't expired.
+	Add(nonce string, exp time.Time) (bool, error)
+}
+
+// memoryNonceStore keeps nonces in memory of current process, expired nonces are evicted lazily on adding.
+type memoryNonceStore struct {
+	sync.Mutex
+	m     map[string]time.Time
+	evict time.Time // next time to evict expired nonces
+}
+
+// NewMemoryNonceStore creates a NonceStore in memory. Replays are only detected by the same instance, so runners
+// with multiple instances behind a load balancer should share a NonceStore, e.g. one backed by Redis.
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{m: make(map[string]time.Time)}
+}
+
+func (s *memoryNonceStore) Add(nonce string, exp time.Time) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	if now.After(s.evict) {
+		s.prune(now)
+		s.evict = now.Add(time.Minute)
+	}
+	if t, ok := s.m[nonce]; ok && now.Before(t) {
+		return false, nil
+	}
+	s.m[nonce] = exp
+	return true, nil
+}
+
+// prune removes nonces expired before now, requests with them are rejected by timestamp already.
+func (s *memoryNonceStore) prune(now time.Time) {
+	for n, t := range s.m {
+		if now.After(t) {
+			delete(s.m, n)
+		}
+	}
+}
+
+// bind decodes request body into v. If `skynet.runner.secret` is set, request must be signed by scheduler with
+// the same secret, and its timestamp must be within `skynet.runner.max_skew`(default 5m) from now.
+func (s *HTTPServer) bind(ctx web.Context, v interface{}) error {
+	secret := config.GetString("skynet.runner.secret")
+	if secret == "" {
+		return ctx.Bind(v)
+	}
+
+	r := ctx.Request()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	err = s.verify(secret, ctx.Header(contract.HeaderTimestamp), ctx.Header(contract.HeaderNonce), ctx.Header(contract.HeaderSignature),
+		r.Method, r.URL.Path, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (s *HTTPServer) verify(secret, timestamp, nonce, signature, method, path string, body []byte) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || nonce == "" || signature == "" {
+		return errors.New("request is not signed")
+	}
+
+	skew := maxSkew()
+	now, t := time.Now(), time.Unix(ts, 0)
+	if d := now.Sub(t); d > skew || d < -skew {
+		return errors.New("request is expired")
+	}
+	if !contract.Verify(secret, ts, nonce, method, path, body, signature) {
+		return errors.New("invalid signature")
+	}
+
+	// a nonce must be kept until its request is rejected by timestamp
+	ok, err := s.nonces.Add(nonce, t.Add(skew))
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.New("request is replayed")
+	}
+	return nil
+}
+
+// maxSkew returns max allowed difference between request timestamp and now.
+func maxSkew() time.Duration {
+	if skew := config.GetDuration("skynet.runner.max_skew"); skew > 0 {
+		return skew
+	}
+	return 5 * time.Minute
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/contract"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "secret"
+	body := []byte(`{"id":"1"}`)
+	s := NewHTTPServer(nil)
+	now := time.Now().Unix()
+	sign := func(ts int64, nonce string) string {
+		return contract.Sign(secret, ts, nonce, "POST", "/task/execute", body)
+	}
+
+	cases := []struct {
+		name      string
+		secret    string
+		path      string
+		timestamp string
+		nonce     string
+		signature string
+		body      []byte
+		ok        bool
+	}{
+		{"valid", secret, "/task/execute", strconv.FormatInt(now, 10), "n1", sign(now, "n1"), body, true},
+		{"replayed", secret, "/task/execute", strconv.FormatInt(now, 10), "n1", sign(now, "n1"), body, false},
+		{"small skew", secret, "/task/execute", strconv.FormatInt(now-60, 10), "n2", sign(now-60, "n2"), body, true},
+		{"expired", secret, "/task/execute", strconv.FormatInt(now-600, 10), "n3", sign(now-600, "n3"), body, false},
+		{"future", secret, "/task/execute", strconv.FormatInt(now+600, 10), "n4", sign(now+600, "n4"), body, false},
+		{"missing timestamp", secret, "/task/execute", "", "n5", sign(now, "n5"), body, false},
+		{"missing nonce", secret, "/task/execute", strconv.FormatInt(now, 10), "", sign(now, ""), body, false},
+		{"missing signature", secret, "/task/execute", strconv.FormatInt(now, 10), "n6", "", body, false},
+		{"wrong secret", "other", "/task/execute", strconv.FormatInt(now, 10), "n7", sign(now, "n7"), body, false},
+		{"tampered body", secret, "/task/execute", strconv.FormatInt(now, 10), "n8", sign(now, "n8"), []byte(`{"id":"2"}`), false},
+		{"tampered nonce", secret, "/task/execute", strconv.FormatInt(now, 10), "n9", sign(now, "n8"), body, false},
+		{"tampered path", secret, "/task/cancel", strconv.FormatInt(now, 10), "n11", sign(now, "n11"), body, false},
+		{"invalid signature", secret, "/task/execute", strconv.FormatInt(now, 10), "n10", "xyz", body, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := s.verify(c.secret, c.timestamp, c.nonce, c.signature, "POST", c.path, c.body)
+			if (err == nil) != c.ok {
+				t.Fatalf("got %v, want ok: %v", err, c.ok)
+			}
+		})
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Now()
+	s := &memoryNonceStore{m: map[string]time.Time{
+		"expired": now.Add(-time.Minute),
+		"valid":   now.Add(time.Minute),
+	}}
+
+	cases := []struct {
+		nonce string
+		ok    bool
+	}{
+		{"valid", false},
+		{"expired", true},
+		{"new", true},
+		{"new", false},
+	}
+	for _, c := range cases {
+		ok, err := s.Add(c.nonce, now.Add(5*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		} else if ok != c.ok {
+			t.Fatalf("nonce %s: got %v, want %v", c.nonce, ok, c.ok)
+		}
+	}
+
+	s.prune(now.Add(10 * time.Minute))
+	if len(s.m) != 0 {
+		t.Fatalf("got %d nonces after pruning, want 0", len(s.m))
+	}
+}
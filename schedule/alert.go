@@ -75,7 +75,7 @@ func (a *Alerter) alert(jobId string, info string) error {
 		"args":      job.Args,
 		"scheduler": job.Scheduler,
 	}
-	if job.Execute.EndTime == nil {
+	if job.Execute.StartTime == nil || job.Execute.EndTime == nil {
 		vars.Set("duration", "")
 	} else {
 		vars.Set("duration", time.Time(*job.Execute.EndTime).Sub(time.Time(*job.Execute.StartTime)).String())
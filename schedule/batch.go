@@ -0,0 +1,141 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/ext/times"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newBatchJob(parent *Job, batch *contract.Batch) *Job {
+	id := primitive.NewObjectID()
+	return &Job{
+		oid:      id,
+		fire:     parent.fire,
+		runner:   parent.runner,
+		timeout:  parent.timeout,
+		dispatch: parent.dispatch,
+		parent:   parent.Id,
+		batch:    batch.Id,
+		Id:       id.Hex(),
+		Task:     parent.Task,
+		Handler:  parent.Handler,
+		Mode:     parent.Mode,
+		Fire:     times.ToUnixMilli(parent.fire),
+		Args:     mergeArgs(parent.Args, batch.Args),
+		Attempt:  1,
+	}
+}
+
+// split asks runner to split job into batches and dispatches each batch as a child job.
+// It returns false if runner doesn't support splitting, then job should be dispatched as usual.
+func (s *Scheduler) split(job *Job, caller Caller, addrs []string) bool {
+	r := caller.Split(addrs, job)
+	switch r.Code {
+	case contract.CodeSuccess:
+	case contract.CodeNotSupported:
+		s.logger.Warnf("handler '%s' of task '%s' doesn't support splitting", job.Handler, job.Task)
+		return false
+	default:
+		s.dispatched(job, &CallResult{Code: r.Code, Info: "failed to split job: " + r.Info})
+		return true
+	}
+
+	count := int32(len(r.Batches))
+	err := s.js.SetBatches(job.Id, count)
+	if err == nil {
+		err = s.js.ModifyDispatch(job.Id, true, fmt.Sprintf("split into %d batches", count), "", time.Time{})
+	}
+	if err != nil {
+		s.logger.Errorf("failed to update job control info: %s", err)
+		return true
+	}
+
+	if count == 0 {
+		now := time.Now()
+		if j, err := s.js.ModifyExecute(job.Id, 0, store.JobStatusSuccess, "", now, now); err != nil {
+			s.logger.Errorf("failed to update job execute info: %s", err)
+		} else if j != nil {
+			s.finish(j, true)
+		}
+		return true
+	}
+
+	for _, b := range r.Batches {
+		child := newBatchJob(job, b)
+		if err = s.save(child); err != nil {
+			s.logger.Errorf("failed to save batch job to db: %s", err)
+			s.settle(job.Id, false)
+			continue
+		}
+		// children are dispatched by pool like other jobs, so a large split doesn't bypass its limits
+		s.pool.Submit(child, true)
+	}
+	return true
+}
+
+// settle counts a finished batch job, parent job is completed when all batch jobs are finished.
+func (s *Scheduler) settle(parent string, success bool) {
+	j, err := s.js.SettleBatch(parent, success)
+	if err != nil {
+		s.logger.Errorf("failed to settle batch of job '%s': %s", parent, err)
+		return
+	}
+
+	b := j.Batches
+	if b == nil || b.Success+b.Failed != b.Total || j.Execute.Status != store.JobStatusUnknown {
+		return
+	}
+
+	var (
+		status = store.JobStatusSuccess
+		info   string
+		start  = time.Time(j.FireTime)
+	)
+	if j.Dispatch.Time != nil {
+		start = time.Time(*j.Dispatch.Time)
+	}
+	if b.Failed > 0 {
+		status = store.JobStatusFailed
+		info = fmt.Sprintf("%d of %d batches failed", b.Failed, b.Total)
+	}
+	if m, err := s.js.ModifyExecute(parent, 0, status, info, start, time.Now()); err != nil {
+		s.logger.Errorf("failed to update job execute info: %s", err)
+		return
+	} else if m == nil {
+		// parent was cancelled or timed out already
+		return
+	}
+
+	if status == store.JobStatusFailed {
+		s.alerter.Alert(parent, info)
+	}
+	s.finish(j, status == store.JobStatusSuccess)
+}
+
+// cancelBatches cancels all running batch jobs of parent job.
+func (s *Scheduler) cancelBatches(parent *store.Job, runner string) error {
+	children, err := s.js.FetchChildren(parent.Id.Hex())
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, c := range children {
+		if c.Dispatch.Status != store.JobStatusSuccess || c.Execute.Status != store.JobStatusUnknown {
+			continue
+		}
+		if r := s.cancel(c, runner); !r.Success() {
+			failed++
+			s.logger.Errorf("failed to cancel batch job '%s': %s", c.Id.Hex(), r.Info)
+		}
+	}
+	if failed > 0 {
+		return errors.Format("failed to cancel %d batch jobs", failed)
+	}
+	return nil
+}
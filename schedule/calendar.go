@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/skynet/store"
+)
+
+const dateLayout = "2006-01-02"
+
+// calendar is the parsed form of store.Calendar.
+type calendar struct {
+	loc       *time.Location
+	weekdays  [7]bool
+	excluded  map[string]bool
+	included  map[string]bool
+	blackouts []*store.CalendarRange
+}
+
+// CheckCalendar validates dates, weekdays and time ranges of calendar.
+func CheckCalendar(c *store.Calendar) error {
+	_, err := newCalendar(c)
+	return err
+}
+
+func newCalendar(c *store.Calendar) (*calendar, error) {
+	loc, err := LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &calendar{
+		loc:       loc,
+		excluded:  make(map[string]bool, len(c.Excluded)),
+		included:  make(map[string]bool, len(c.Included)),
+		blackouts: c.Blackouts,
+	}
+	for _, d := range c.Weekdays {
+		if d < 0 || d > 6 {
+			return nil, errors.Format("invalid weekday of calendar '%s': %d", c.Name, d)
+		}
+		cal.weekdays[d] = true
+	}
+	for _, d := range c.Excluded {
+		if _, err = time.Parse(dateLayout, d); err != nil {
+			return nil, errors.Format("invalid date of calendar '%s': %s", c.Name, d)
+		}
+		cal.excluded[d] = true
+	}
+	for _, d := range c.Included {
+		if _, err = time.Parse(dateLayout, d); err != nil {
+			return nil, errors.Format("invalid date of calendar '%s': %s", c.Name, d)
+		}
+		cal.included[d] = true
+	}
+	for _, r := range c.Blackouts {
+		if !time.Time(r.Start).Before(time.Time(r.End)) {
+			return nil, errors.Format("invalid time range of calendar '%s': %s - %s", c.Name, r.Start, r.End)
+		}
+	}
+	return cal, nil
+}
+
+// block returns the end of exclusion which t falls inside, it returns zero time if t is not excluded.
+func (c *calendar) block(t time.Time) time.Time {
+	for _, r := range c.blackouts {
+		if start, end := time.Time(r.Start), time.Time(r.End); !t.Before(start) && t.Before(end) {
+			return end
+		}
+	}
+
+	lt := t.In(c.loc)
+	date := lt.Format(dateLayout)
+	if c.included[date] {
+		return time.Time{}
+	}
+	if c.excluded[date] || c.weekdays[lt.Weekday()] {
+		y, m, d := lt.Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, c.loc)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/store"
+)
+
+func TestCalendarBlock(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	date := func(day, hour int) time.Time {
+		return time.Date(2024, 1, day, hour, 0, 0, 0, shanghai)
+	}
+
+	// 2024-01-06 and 2024-01-07 are weekend
+	cal, err := newCalendar(&store.Calendar{
+		Name:     "test",
+		TimeZone: "Asia/Shanghai",
+		Weekdays: []int32{0, 6},
+		Excluded: []string{"2024-01-01"},
+		Included: []string{"2024-01-07"},
+		Blackouts: []*store.CalendarRange{
+			{Start: store.Time(date(3, 22)), End: store.Time(date(4, 2))},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"workday", date(2, 10), time.Time{}},
+		{"excluded date", date(1, 10), date(2, 0)},
+		{"excluded weekday", date(6, 10), date(7, 0)},
+		{"included weekday", date(7, 10), time.Time{}},
+		{"blackout start", date(3, 22), date(4, 2)},
+		{"inside blackout", date(4, 1), date(4, 2)},
+		{"blackout end", date(4, 2), time.Time{}},
+		// 2024-01-01 00:30 in Shanghai is 2023-12-31 16:30 in UTC, date is checked in zone of calendar
+		{"zone of calendar", time.Date(2023, 12, 31, 16, 30, 0, 0, time.UTC), date(2, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := cal.block(c.t); !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCheckCalendar(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name string
+		c    *store.Calendar
+		ok   bool
+	}{
+		{"valid", &store.Calendar{Weekdays: []int32{0, 6}, Excluded: []string{"2024-01-01"}}, true},
+		{"invalid zone", &store.Calendar{TimeZone: "Invalid/Zone"}, false},
+		{"invalid weekday", &store.Calendar{Weekdays: []int32{7}}, false},
+		{"invalid excluded date", &store.Calendar{Excluded: []string{"2024/01/01"}}, false},
+		{"invalid included date", &store.Calendar{Included: []string{"2024-13-01"}}, false},
+		{"invalid blackout", &store.Calendar{Blackouts: []*store.CalendarRange{{Start: store.Time(now), End: store.Time(now)}}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := CheckCalendar(c.c); (err == nil) != c.ok {
+				t.Fatalf("got %v, want ok: %v", err, c.ok)
+			}
+		})
+	}
+}
+
+func TestTaskItemSkipsExcludedFires(t *testing.T) {
+	calendars := map[string]*store.Calendar{
+		"weekend": {Name: "weekend", TimeZone: "UTC", Weekdays: []int32{0, 6}},
+		"freeze": {Name: "freeze", TimeZone: "UTC", Blackouts: []*store.CalendarRange{{
+			Start: store.Time(time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)),
+			End:   store.Time(time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)),
+		}}},
+	}
+	task := &store.Task{Name: "test", TimeZone: "UTC", Triggers: []string{"0 0 10 * * *"}, Calendars: []string{"weekend", "freeze"}}
+
+	// 2024-01-05 is Friday, weekend and the freeze until 2024-01-09 12:00 are skipped
+	start := time.Date(2024, 1, 5, 11, 0, 0, 0, time.UTC)
+	fires, err := NextFireTimes(task, calendars, start, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{
+		time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 11, 10, 0, 0, 0, time.UTC),
+	}
+	if len(fires) != len(want) || !fires[0].Equal(want[0]) || !fires[1].Equal(want[1]) {
+		t.Fatalf("got %v, want %v", fires, want)
+	}
+
+	task.Calendars = append(task.Calendars, "missing")
+	if _, err = NextFireTimes(task, calendars, start, 1); err == nil {
+		t.Fatal("missing calendar: error expected")
+	}
+}
+
+func TestTaskItemSkip(t *testing.T) {
+	c := &store.Calendar{Name: "weekend", TimeZone: "UTC", Weekdays: []int32{0, 6}}
+	task := &store.Task{Name: "test", Calendars: []string{"weekend"}}
+	task.Trigger.Type = store.TriggerDelay
+	task.Trigger.Interval = 60
+
+	item, err := NewItem(task, map[string]*store.Calendar{"weekend": c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"workday", time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC), time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC)},
+		{"weekend", time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := item.skip(c.t); !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
@@ -2,41 +2,137 @@ package schedule
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"math/rand"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cuigh/auxo/config"
 	"github.com/cuigh/auxo/errors"
 	"github.com/cuigh/auxo/log"
 	"github.com/cuigh/auxo/net/web"
+	"github.com/cuigh/skynet/contract"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// httpTimeout is the default timeout of requests to HTTP runners, so a hung runner doesn't block dispatching forever.
+const httpTimeout = 30 * time.Second
+
 // Caller format: http://abc, simple://
 type Caller interface {
 	// Call dispatches task to remote runner.
 	Call(addrs []string, t *Job) *CallResult
+	// Cancel asks remote runner to cancel a running job.
+	Cancel(addrs []string, id string) *CallResult
 	// Split asks remote runner how to split task.
-	//Split(addrs []string, t *Job) SplitResult
+	Split(addrs []string, t *Job) *contract.SplitResult
 }
 
 type CallResult struct {
-	Code int32  `json:"code"`
-	Info string `json:"info,omitempty"`
+	Code    int32  `json:"code"`
+	Info    string `json:"info,omitempty"`
+	Address string `json:"-"` // address of runner which accepted the job
 }
 
 func (r *CallResult) Success() bool {
 	return r.Code == 0
 }
 
+// HTTPOptions are options of requests to HTTP runners, they are loaded from config `skynet.http`, options of
+// `skynet.http.runners` are applied to runners whose address starts with Address, empty fields are inherited.
+type HTTPOptions struct {
+	Address  string            `option:"address"` // prefix of runner address, e.g. https://task.test.com
+	Timeout  time.Duration     `option:"timeout"` // timeout of each request, task's dispatch timeout takes precedence
+	CA       string            `option:"ca"`      // CA certificate file to verify runner
+	Cert     string            `option:"cert"`    // client certificate file
+	Key      string            `option:"key"`     // private key file of client certificate
+	Insecure bool              `option:"insecure"`
+	Headers  map[string]string `option:"headers"` // static headers of every request
+	Secret   string            `option:"secret"`  // shared secret to sign requests, runner verifies it by `skynet.runner.secret`
+	Runners  []*HTTPOptions    `option:"runners"`
+	client   *http.Client
+}
+
+// inherit fills empty fields with default options.
+func (o *HTTPOptions) inherit(def *HTTPOptions) {
+	if o.Timeout <= 0 {
+		o.Timeout = def.Timeout
+	}
+	if o.CA == "" {
+		o.CA = def.CA
+	}
+	if o.Cert == "" && o.Key == "" {
+		o.Cert, o.Key = def.Cert, def.Key
+	}
+	o.Insecure = o.Insecure || def.Insecure
+	if o.Secret == "" {
+		o.Secret = def.Secret
+	}
+	headers := make(map[string]string)
+	for k, v := range def.Headers {
+		headers[k] = v
+	}
+	for k, v := range o.Headers {
+		headers[k] = v
+	}
+	o.Headers = headers
+}
+
+func (o *HTTPOptions) createClient() error {
+	cfg, err := contract.LoadTLS(o.CA, o.Cert, o.Key, o.Insecure)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	o.client = &http.Client{Transport: transport}
+	return nil
+}
+
+// HTTPCaller dispatches jobs to HTTP runners, format of runner: http://host:port or https://host:port.
 type HTTPCaller struct {
+	def     *HTTPOptions
+	runners []*HTTPOptions // sorted by length of address descendingly, so the longest prefix matches first
+}
+
+// NewHTTPCaller creates HTTPCaller with options in config `skynet.http`, it panics if options are invalid.
+func NewHTTPCaller() *HTTPCaller {
+	def := &HTTPOptions{}
+	if config.Exist("skynet.http") {
+		if err := config.UnmarshalOption("skynet.http", def); err != nil {
+			panic(errors.Wrap(err, "failed to load options of HTTP caller"))
+		}
+	}
+	if def.Timeout <= 0 {
+		def.Timeout = httpTimeout
+	}
+
+	c := &HTTPCaller{def: def, runners: def.Runners}
+	sort.SliceStable(c.runners, func(i, j int) bool {
+		return len(c.runners[i].Address) > len(c.runners[j].Address)
+	})
+	for _, o := range append([]*HTTPOptions{def}, c.runners...) {
+		if o != def {
+			o.inherit(def)
+		}
+		if err := o.createClient(); err != nil {
+			panic(errors.Wrap(err, "failed to create HTTP client for runner '%s'", o.Address))
+		}
+	}
+	return c
 }
 
-func (c HTTPCaller) Call(addrs []string, j *Job) (r *CallResult) {
+func (c *HTTPCaller) Call(addrs []string, j *Job) (r *CallResult) {
 	addrs = shuffle(addrs)
 	for _, addr := range addrs {
-		r = c.call(addr+"/task/execute", j)
+		r = c.call(addr+"/task/execute", j, j.dispatch)
 		if r.Success() {
+			r.Address = addr
 			return
 		}
 		log.Get("schedule").Errorf("call with address '%s' failed: %s", addr, r.Info)
@@ -44,27 +140,76 @@ func (c HTTPCaller) Call(addrs []string, j *Job) (r *CallResult) {
 	return
 }
 
-func (c HTTPCaller) call(addr string, j *Job) *CallResult {
-	//return &CallResult{
-	//	Code: 1,
-	//	Info: fmt.Sprintf("not implemented: %s-%s", j.Task, times.Format(j.Fire, "yyyyMMddHHmmss")),
-	//}
+func (c *HTTPCaller) Split(addrs []string, j *Job) (r *contract.SplitResult) {
+	addrs = shuffle(addrs)
+	for _, addr := range addrs {
+		r = &contract.SplitResult{}
+		if err := c.do(addr+"/task/split", j, r, j.dispatch); err != nil {
+			r.Code, r.Info = contract.CodeFailed, err.Error()
+		}
+		if r.Code != contract.CodeFailed {
+			return
+		}
+		log.Get("schedule").Errorf("split with address '%s' failed: %s", addr, r.Info)
+	}
+	if r == nil {
+		r = &contract.SplitResult{Code: contract.CodeFailed, Info: "no available address"}
+	}
+	return
+}
+
+// Cancel sends cancel request to all addresses because runner which accepted the job is unknown.
+func (c *HTTPCaller) Cancel(addrs []string, id string) (r *CallResult) {
+	r = &CallResult{Code: 1, Info: "no available address"}
+	for _, addr := range addrs {
+		if cr := c.call(addr+"/task/cancel", contract.CancelParam{Id: id}, 0); cr.Success() {
+			r = cr
+		} else {
+			log.Get("schedule").Errorf("cancel with address '%s' failed: %s", addr, cr.Info)
+			if !r.Success() {
+				r = cr
+			}
+		}
+	}
+	return
+}
 
+func (c *HTTPCaller) call(addr string, j interface{}, timeout time.Duration) *CallResult {
 	var r CallResult
-	if err := c.do(addr, j, &r); err != nil {
+	if err := c.do(addr, j, &r, timeout); err != nil {
 		r.Code = 1
 		r.Info = err.Error()
 	}
 	return &r
 }
 
-func (c HTTPCaller) do(url string, args, result interface{}) error {
+// do posts args to url, options of runner's timeout is used if timeout is 0.
+func (c *HTTPCaller) do(url string, args, result interface{}, timeout time.Duration) error {
 	data, err := json.Marshal(args)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Post(url, web.MIMEApplicationJSONCharsetUTF8, bytes.NewBuffer(data))
+	opts := c.options(url)
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutOr(timeout, opts.Timeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(web.HeaderContentType, web.MIMEApplicationJSONCharsetUTF8)
+	if opts.Secret != "" {
+		ts, nonce := time.Now().Unix(), primitive.NewObjectID().Hex()
+		req.Header.Set(contract.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(contract.HeaderNonce, nonce)
+		req.Header.Set(contract.HeaderSignature, contract.Sign(opts.Secret, ts, nonce, req.Method, req.URL.Path, data))
+	}
+
+	resp, err := opts.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -78,6 +223,16 @@ func (c HTTPCaller) do(url string, args, result interface{}) error {
 	return d.Decode(result)
 }
 
+// options returns options of runner which serves url.
+func (c *HTTPCaller) options(url string) *HTTPOptions {
+	for _, o := range c.runners {
+		if strings.HasPrefix(url, o.Address) {
+			return o
+		}
+	}
+	return c.def
+}
+
 func shuffle(addrs []string) []string {
 	if l := len(addrs); l > 1 {
 		arr := make([]string, len(addrs))
@@ -0,0 +1,228 @@
+package schedule
+
+import (
+	"hash/crc32"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cuigh/auxo/app"
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/store"
+)
+
+// replicas is the count of virtual nodes of each node on hash ring, it keeps tasks balanced among nodes.
+const replicas = 64
+
+// Cluster maintains membership of scheduler nodes by heartbeats in store, and shards tasks across
+// alive nodes by consistent hashing so that each task is scheduled by only one node. Membership may
+// differ among nodes for a short while, so the lock is still needed to avoid double dispatch.
+type Cluster struct {
+	node     string
+	enabled  bool
+	start    time.Time
+	interval time.Duration
+	ns       store.NodeStore
+	locker   sync.RWMutex
+	nodes    []string // ids of alive nodes, sorted
+	ring     *hashRing
+	version  int           // increased whenever membership changed
+	changed  chan struct{} // notified when membership changed
+	report   func(n *store.Node)
+	done     chan struct{} // closed after heartbeat loop exited
+	logger   log.Logger
+}
+
+func NewCluster(node string, ns store.NodeStore, logger log.Logger) *Cluster {
+	interval := config.GetDuration("skynet.cluster.heartbeat")
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	mode := config.GetString("skynet.cluster.mode")
+	return &Cluster{
+		node:     node,
+		enabled:  mode == "" || mode == "shard",
+		start:    time.Now(),
+		interval: interval,
+		ns:       ns,
+		changed:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		logger:   logger,
+	}
+}
+
+// Start registers current node and keeps membership up to date until closer is closed,
+// report fills runtime status of scheduler into heartbeats.
+func (c *Cluster) Start(closer <-chan struct{}, report func(n *store.Node)) {
+	c.report = report
+	c.refresh()
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.refresh()
+			case <-closer:
+				return
+			}
+		}
+	}()
+}
+
+// Stop unregisters current node after heartbeat loop exited, so other nodes take over its tasks immediately.
+func (c *Cluster) Stop() {
+	select {
+	case <-c.done:
+	case <-time.After(5 * time.Second):
+		// a heartbeat may be blocked by store, the node will be treated as dead after it missed 3 heartbeats
+	}
+	if err := c.ns.Delete(c.node); err != nil {
+		c.logger.Errorf("failed to unregister node '%s': %s", c.node, err)
+	}
+}
+
+// Owns returns true if task should be scheduled by current node.
+func (c *Cluster) Owns(task string) bool {
+	if !c.enabled {
+		return true
+	}
+
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+
+	// schedule all tasks if membership was never fetched, the lock prevents double dispatch
+	if c.ring == nil {
+		return true
+	}
+	return c.ring.get(task) == c.node
+}
+
+// Version returns version of membership, task heap must be rebuilt if it was loaded with another version.
+func (c *Cluster) Version() int {
+	if !c.enabled {
+		return 0
+	}
+
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	return c.version
+}
+
+// Nodes returns ids of alive nodes.
+func (c *Cluster) Nodes() []string {
+	c.locker.RLock()
+	defer c.locker.RUnlock()
+	return c.nodes
+}
+
+func (c *Cluster) refresh() {
+	now := time.Now()
+	host, _ := os.Hostname()
+	lock := config.GetString("skynet.lock")
+	if lock == "" {
+		lock = "null"
+	}
+	n := &store.Node{
+		Id:        c.node,
+		Host:      host,
+		Pid:       os.Getpid(),
+		Version:   app.Version,
+		Lock:      lock,
+		Interval:  int32(c.interval / time.Second),
+		StartTime: store.Time(c.start),
+		Heartbeat: store.Time(now),
+	}
+	if c.report != nil {
+		c.report(n)
+	}
+	if err := c.ns.Heartbeat(n); err != nil {
+		c.logger.Errorf("failed to send heartbeat of node '%s': %s", c.node, err)
+		return
+	}
+
+	// node is dead if it missed 3 heartbeats
+	alive, err := c.ns.FetchAlive(now.Add(-3 * c.interval))
+	if err != nil {
+		c.logger.Errorf("failed to fetch alive nodes: %s", err)
+		return
+	}
+
+	nodes := []string{c.node}
+	for _, n := range alive {
+		if n.Id != c.node {
+			nodes = append(nodes, n.Id)
+		}
+	}
+	sort.Strings(nodes)
+
+	c.locker.Lock()
+	if equalStrings(c.nodes, nodes) {
+		c.locker.Unlock()
+		return
+	}
+	c.nodes, c.ring = nodes, newHashRing(nodes)
+	c.version++
+	c.locker.Unlock()
+
+	c.logger.Infof("cluster nodes changed: %v", nodes)
+	// heap may be loaded before membership was fetched at the first time, e.g. heartbeat failed on startup,
+	// so always notify, TaskFetcher skips rebuilding if heap was loaded with current membership
+	if c.enabled {
+		select {
+		case c.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// hashRing is a consistent hash ring, only a part of tasks are moved when nodes join or leave.
+type hashRing struct {
+	hashes []uint32
+	owners map[uint32]string
+}
+
+func newHashRing(nodes []string) *hashRing {
+	r := &hashRing{
+		hashes: make([]uint32, 0, len(nodes)*replicas),
+		owners: make(map[uint32]string, len(nodes)*replicas),
+	}
+	for _, n := range nodes {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(n + "#" + strconv.Itoa(i)))
+			if _, ok := r.owners[h]; !ok {
+				r.hashes = append(r.hashes, h)
+				r.owners[h] = n
+			}
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+func (r *hashRing) get(key string) string {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
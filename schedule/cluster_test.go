@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/store"
+)
+
+func ringOwners(r *hashRing, tasks int) map[string]string {
+	owners := make(map[string]string, tasks)
+	for i := 0; i < tasks; i++ {
+		task := "task-" + strconv.Itoa(i)
+		owners[task] = r.get(task)
+	}
+	return owners
+}
+
+func TestHashRingBalance(t *testing.T) {
+	const tasks = 3000
+	cases := [][]string{
+		{"a"},
+		{"a", "b"},
+		{"a", "b", "c"},
+		{"a", "b", "c", "d", "e"},
+	}
+	for _, nodes := range cases {
+		t.Run(strconv.Itoa(len(nodes)), func(t *testing.T) {
+			counts := make(map[string]int)
+			for _, owner := range ringOwners(newHashRing(nodes), tasks) {
+				counts[owner]++
+			}
+
+			// every node gets at least half of its fair share
+			fair := tasks / len(nodes)
+			for _, n := range nodes {
+				if counts[n] < fair/2 {
+					t.Fatalf("node %s owns %d of %d tasks: %v", n, counts[n], tasks, counts)
+				}
+			}
+			if len(counts) != len(nodes) {
+				t.Fatalf("unexpected owners: %v", counts)
+			}
+		})
+	}
+}
+
+func TestHashRingConsistency(t *testing.T) {
+	const tasks = 3000
+	cases := []struct {
+		name   string
+		before []string
+		after  []string
+	}{
+		{"node joins", []string{"a", "b", "c"}, []string{"a", "b", "c", "d"}},
+		{"node leaves", []string{"a", "b", "c", "d"}, []string{"a", "b", "d"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			before, after := ringOwners(newHashRing(c.before), tasks), ringOwners(newHashRing(c.after), tasks)
+			alive := make(map[string]bool)
+			for _, n := range c.after {
+				alive[n] = true
+			}
+
+			moved := 0
+			for task, owner := range before {
+				if after[task] == owner {
+					continue
+				}
+				moved++
+				// only tasks of the left node or tasks taken by the joined node move
+				if alive[owner] && contains(c.before, after[task]) {
+					t.Fatalf("task %s moved from %s to %s", task, owner, after[task])
+				}
+			}
+			if moved == 0 || moved > tasks/2 {
+				t.Fatalf("%d of %d tasks moved", moved, tasks)
+			}
+		})
+	}
+}
+
+func TestHashRingStable(t *testing.T) {
+	a, b := newHashRing([]string{"a", "b", "c"}), newHashRing([]string{"a", "b", "c"})
+	for task, owner := range ringOwners(a, 100) {
+		if b.get(task) != owner {
+			t.Fatalf("owner of task %s differs: %s, %s", task, owner, b.get(task))
+		}
+	}
+}
+
+func TestClusterOwns(t *testing.T) {
+	ring := newHashRing([]string{"a", "b"})
+	owned := ""
+	for i := 0; owned == ""; i++ {
+		if task := "task-" + strconv.Itoa(i); ring.get(task) == "b" {
+			owned = task
+		}
+	}
+
+	cases := []struct {
+		name    string
+		node    string
+		enabled bool
+		ring    *hashRing
+		want    bool
+	}{
+		{"disabled", "a", false, ring, true},
+		{"membership unknown", "a", true, nil, true},
+		{"owned by other node", "a", true, ring, false},
+		{"owned by current node", "b", true, ring, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := &Cluster{node: c.node, enabled: c.enabled, ring: c.ring}
+			if got := cluster.Owns(owned); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeNodeStore struct {
+	store.NodeStore
+	alive []*store.Node
+	last  *store.Node // last heartbeat
+	err   error
+}
+
+func (s *fakeNodeStore) Heartbeat(n *store.Node) error {
+	s.last = n
+	return s.err
+}
+
+func (s *fakeNodeStore) FetchAlive(since time.Time) ([]*store.Node, error) {
+	return s.alive, s.err
+}
+
+func TestClusterRefresh(t *testing.T) {
+	ns := &fakeNodeStore{alive: []*store.Node{{Id: "b"}}}
+	c := &Cluster{node: "a", enabled: true, interval: time.Second, ns: ns, changed: make(chan struct{}, 1), logger: log.Get("schedule")}
+	changed := func() bool {
+		select {
+		case <-c.changed:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// heap may be loaded before membership is fetched at the first time, TaskFetcher skips rebuilding by version
+	c.refresh()
+	if !reflect.DeepEqual(c.nodes, []string{"a", "b"}) || !changed() || c.Version() != 1 {
+		t.Fatalf("unexpected nodes after first refresh: %v", c.nodes)
+	}
+
+	c.refresh()
+	if changed() || c.Version() != 1 {
+		t.Fatal("membership is not changed")
+	}
+
+	ns.alive = append(ns.alive, &store.Node{Id: "c"})
+	c.refresh()
+	if !reflect.DeepEqual(c.nodes, []string{"a", "b", "c"}) || !changed() || c.Version() != 2 {
+		t.Fatalf("rebalancing expected, nodes: %v", c.nodes)
+	}
+
+	// membership is kept if store fails
+	ns.err = errors.New("store is down")
+	c.refresh()
+	if len(c.nodes) != 3 || changed() {
+		t.Fatalf("membership should be kept, nodes: %v", c.nodes)
+	}
+}
+
+func TestClusterReport(t *testing.T) {
+	ns := &fakeNodeStore{}
+	c := &Cluster{node: "a", interval: 10 * time.Second, ns: ns, changed: make(chan struct{}, 1), logger: log.Get("schedule")}
+	c.report = func(n *store.Node) { n.HeapSize = 5 }
+
+	c.refresh()
+	if n := ns.last; n == nil || n.Id != "a" || n.Interval != 10 || n.HeapSize != 5 {
+		t.Fatalf("unexpected heartbeat: %+v", n)
+	}
+}
@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"fmt"
+
+	"github.com/cuigh/skynet/store"
+)
+
+// admit takes a concurrency slot of task before dispatching a new job, it returns false if job should not be
+// dispatched now. Slots are taken atomically, so the limit holds even if jobs are created on several nodes at once.
+func (s *Scheduler) admit(job *Job) bool {
+	if job.concurrency <= 0 || job.parent != "" {
+		return true
+	}
+
+	ok, err := s.ss.Acquire(job.Task, job.oid, job.concurrency)
+	if err != nil {
+		s.logger.Errorf("failed to acquire concurrency slot of task '%s': %s", job.Task, err)
+		return true
+	} else if ok {
+		return true
+	}
+
+	switch job.overlap {
+	case store.OverlapQueue:
+		if err = s.js.Queue(job.oid); err != nil {
+			s.logger.Errorf("failed to queue job '%s': %s", job.Id, err)
+			return true
+		}
+		s.logger.Infof("job '%s' is queued because %d jobs of task '%s' are running", job.Id, job.concurrency, job.Task)
+		// running jobs may be finished before this job was queued
+		s.spawn(func() { s.release(job.Task) })
+		return false
+	case store.OverlapReplace:
+		jobs, err := s.js.FetchActive(job.Task, job.oid)
+		if err != nil {
+			s.logger.Errorf("failed to fetch active jobs of task '%s': %s", job.Task, err)
+		}
+		for _, j := range jobs {
+			if j.Dispatch.Status != store.JobStatusSuccess {
+				continue
+			}
+			if r := s.cancel(j, job.runner); !r.Success() {
+				s.logger.Warnf("failed to cancel job '%s' replaced by job '%s': %s", j.Id.Hex(), job.Id, r.Info)
+			}
+		}
+		// slots of replaced jobs are released after they are cancelled
+		if _, err = s.ss.Acquire(job.Task, job.oid, 0); err != nil {
+			s.logger.Errorf("failed to acquire concurrency slot of task '%s': %s", job.Task, err)
+		}
+		return true
+	default:
+		info := fmt.Sprintf("skipped because %d jobs of task are running", job.concurrency)
+		if err = s.js.Skip(job.oid, info); err != nil {
+			s.logger.Errorf("failed to skip job '%s': %s", job.Id, err)
+		}
+		s.logger.Infof("job '%s' of task '%s' is %s", job.Id, job.Task, info)
+		// skipped job will never be executed, so it's finished now, e.g. fixed-delay task is scheduled and
+		// workflow node is failed
+		s.spawn(func() {
+			s.finish(&store.Job{Id: job.oid, Task: job.Task, Mode: job.Mode, Run: job.run, Node: job.node}, false)
+		})
+		return false
+	}
+}
+
+// vacate frees concurrency slot of a finished job and dispatches queued jobs of its task.
+func (s *Scheduler) vacate(j *store.Job) {
+	if err := s.ss.Release(j.Task, j.Id); err != nil {
+		s.logger.Errorf("failed to release concurrency slot of job '%s': %s", j.Id.Hex(), err)
+	}
+	s.release(j.Task)
+}
+
+// release dispatches queued jobs of task as long as concurrency limit allows.
+func (s *Scheduler) release(task string) {
+	t, err := s.tf.Find(task)
+	if err != nil {
+		s.logger.Errorf("failed to find task '%s': %s", task, err)
+		return
+	}
+
+	for {
+		j, err := s.js.FetchQueued(task)
+		if err != nil {
+			s.logger.Errorf("failed to fetch queued job of task '%s': %s", task, err)
+			return
+		} else if j == nil {
+			return
+		}
+
+		// take a slot before dequeuing, so a dequeued job never exceeds the limit
+		if ok, err := s.ss.Acquire(task, j.Id, t.Concurrency.Max); err != nil {
+			s.logger.Errorf("failed to acquire concurrency slot of task '%s': %s", task, err)
+			return
+		} else if !ok {
+			return
+		}
+
+		dj, err := s.js.Dequeue(j.Id)
+		if err != nil || dj == nil {
+			// dequeued by another node or cancelled, so the slot is given back
+			if e := s.ss.Release(task, j.Id); e != nil {
+				s.logger.Errorf("failed to release concurrency slot of job '%s': %s", j.Id.Hex(), e)
+			}
+			if err != nil {
+				s.logger.Errorf("failed to dequeue job '%s': %s", j.Id.Hex(), err)
+				return
+			}
+			continue
+		}
+
+		s.logger.Infof("dispatch queued job '%s' of task '%s'", dj.Id.Hex(), task)
+		s.pool.Submit(newRetryJob(dj, t), true)
+	}
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/skynet/store"
+)
+
+// Submit creates a manual job of task which is dispatched at the specified time, it is dispatched immediately
+// if at is not in future. Delayed jobs are persisted, so they survive restarts of scheduler nodes.
+func (s *Scheduler) Submit(name string, args data.Options, at time.Time) (string, error) {
+	task, err := s.tf.Find(name)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if !at.After(now) {
+		job := NewJob(task, args, ModeManual, now)
+		s.call(job, false)
+		return job.Id, nil
+	}
+
+	job := NewJob(task, args, ModeManual, at)
+	job.delayed = true
+	if err = s.save(job); err != nil {
+		return "", err
+	}
+	s.logger.Infof("job '%s' of task '%s' is delayed until %s", job.Id, job.Task, at)
+
+	// wake up the loop in case that job is due before next polling
+	select {
+	case s.delayed <- struct{}{}:
+	default:
+	}
+	return job.Id, nil
+}
+
+// delay dispatches delayed jobs when they are due. Jobs may be submitted to any node, so each node polls
+// the earliest fire time periodically and every due job is claimed atomically before dispatching.
+func (s *Scheduler) delay() {
+	interval := config.GetDuration("skynet.delay_interval")
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	var t Timer
+	defer t.Stop()
+
+	for {
+		t.Reset(s.dispatchDue(interval))
+		select {
+		case <-t.C:
+		case <-s.delayed:
+		case <-s.closer:
+			return
+		}
+	}
+}
+
+// dispatchDue dispatches due jobs and returns how long to wait before next polling.
+func (s *Scheduler) dispatchDue(interval time.Duration) time.Duration {
+	const limit = 100
+
+	jobs, err := s.js.FetchDue(time.Now(), limit)
+	if err != nil {
+		s.logger.Errorf("failed to fetch due jobs: %s", err)
+		return interval
+	}
+	for _, j := range jobs {
+		ok, err := s.js.Claim(j.Id, s.node)
+		if err != nil {
+			s.logger.Errorf("failed to claim job '%s': %s", j.Id.Hex(), err)
+			continue
+		} else if !ok {
+			// claimed by another node or cancelled
+			continue
+		}
+		j := j
+		s.spawn(func() { s.dispatchDelayed(j) })
+	}
+	if len(jobs) == limit {
+		return 0
+	}
+
+	next, err := s.js.NextDue()
+	if err != nil {
+		s.logger.Errorf("failed to fetch fire time of delayed jobs: %s", err)
+		return interval
+	}
+	if d := time.Until(next); !next.IsZero() && d < interval {
+		if d < 0 {
+			d = 0
+		}
+		return d
+	}
+	return interval
+}
+
+func (s *Scheduler) dispatchDelayed(j *store.Job) {
+	t, err := s.tf.Find(j.Task)
+	if err != nil {
+		s.logger.Errorf("failed to find task '%s': %s", j.Task, err)
+		if err = s.js.ModifyDispatch(j.Id.Hex(), false, "task not found", "", time.Time{}); err != nil {
+			s.logger.Errorf("failed to update job control info: %s", err)
+		}
+		return
+	}
+
+	job := newRetryJob(j, t)
+	// retries handed over by a stopping node were admitted already
+	if j.Attempt <= 1 {
+		job.concurrency, job.overlap = t.Concurrency.Max, t.Concurrency.Overlap
+	}
+	if s.admit(job) {
+		s.pool.Submit(job, true)
+	}
+}
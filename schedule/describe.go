@@ -0,0 +1,283 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fields of cron expression
+const (
+	fieldSecond = iota
+	fieldMinute
+	fieldHour
+	fieldDom
+	fieldMonth
+	fieldDow
+)
+
+// aliases holds names of values accepted by parser.
+var aliases = [...]map[string]int{
+	fieldMonth: {"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12},
+	fieldDow:   {"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6},
+}
+
+// cronWords holds words used to describe a field of cron expression.
+type cronWords struct {
+	point string   // format of a single value, e.g. %d时
+	span  string   // unit of a step, e.g. 小时
+	every string   // description of *
+	names []string // display names of values, e.g. names of weekdays
+}
+
+// cronLocale holds words and formats used to describe cron expressions in a language.
+type cronLocale struct {
+	descriptors map[string]string
+	interval    string // format of @every
+	zone        string // format of description with time zone
+	either      string // format of day matched by day of month or day of week
+	monthDate   string // format of date in specified months
+	monthly     string // format of date in every month
+	daily       string // description of every day
+	at          string // format of date with time
+	sep         string // separator of values in a field
+	join        string // separator of fields
+	between     string // format of range
+	from        string // format of start value with step
+	step        string // format of step
+	stepOf      string // format of value with step
+	fields      [6]cronWords
+}
+
+// locales of trigger description, triggers are described in Chinese by default.
+var locales = map[string]*cronLocale{
+	"zh": {
+		descriptors: map[string]string{
+			"@yearly":   "每年1月1日 00:00:00",
+			"@annually": "每年1月1日 00:00:00",
+			"@monthly":  "每月1日 00:00:00",
+			"@weekly":   "每周日 00:00:00",
+			"@daily":    "每天 00:00:00",
+			"@midnight": "每天 00:00:00",
+			"@hourly":   "每小时整点",
+		},
+		interval:  "每隔 %s",
+		zone:      "%s（%s）",
+		either:    "%s或%s",
+		monthDate: "%s%s",
+		monthly:   "每月%s",
+		daily:     "每天",
+		at:        "%s %02d:%02d:%02d",
+		sep:       "、",
+		join:      " ",
+		between:   "%s至%s",
+		from:      "从%s起",
+		step:      "每隔 %s %s",
+		stepOf:    "%s%s",
+		fields: [6]cronWords{
+			fieldSecond: {point: "%d秒", span: "秒", every: "每秒"},
+			fieldMinute: {point: "%d分", span: "分钟", every: "每分钟"},
+			fieldHour:   {point: "%d时", span: "小时", every: "每小时"},
+			fieldDom:    {point: "%d日", span: "天", every: "每天"},
+			fieldMonth:  {point: "%d月", span: "个月", every: "每月"},
+			fieldDow:    {span: "天", every: "每天", names: []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}},
+		},
+	},
+	"en": {
+		descriptors: map[string]string{
+			"@yearly":   "at 00:00:00 on January 1",
+			"@annually": "at 00:00:00 on January 1",
+			"@monthly":  "at 00:00:00 on day 1 of every month",
+			"@weekly":   "at 00:00:00 on Sunday",
+			"@daily":    "at 00:00:00 every day",
+			"@midnight": "at 00:00:00 every day",
+			"@hourly":   "at the start of every hour",
+		},
+		interval:  "every %s",
+		zone:      "%s (%s)",
+		either:    "%s or %s",
+		monthDate: "%[2]s in %[1]s",
+		monthly:   "%s of every month",
+		daily:     "every day",
+		at:        "%s at %02d:%02d:%02d",
+		sep:       ", ",
+		join:      "; ",
+		between:   "%s to %s",
+		from:      "from %s",
+		step:      "every %s %s",
+		stepOf:    "%s %s",
+		fields: [6]cronWords{
+			fieldSecond: {point: "second %d", span: "seconds", every: "every second"},
+			fieldMinute: {point: "minute %d", span: "minutes", every: "every minute"},
+			fieldHour:   {point: "hour %d", span: "hours", every: "every hour"},
+			fieldDom:    {point: "day %d", span: "days", every: "every day"},
+			fieldMonth: {span: "months", every: "every month", names: []string{"", "January", "February", "March",
+				"April", "May", "June", "July", "August", "September", "October", "November", "December"}},
+			fieldDow: {span: "days", every: "every day", names: []string{"Sunday", "Monday", "Tuesday", "Wednesday",
+				"Thursday", "Friday", "Saturday"}},
+		},
+	},
+}
+
+// DescribeTrigger returns a readable description of a valid cron expression in lang(zh or en),
+// it is described in Chinese if lang is not supported.
+func DescribeTrigger(expr, lang string) string {
+	l, ok := locales[lang]
+	if !ok {
+		l = locales["zh"]
+	}
+
+	expr = strings.TrimSpace(expr)
+	var zone string
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		i := strings.IndexAny(expr, " \t")
+		if i < 0 {
+			return expr
+		}
+		zone = expr[strings.Index(expr, "=")+1 : i]
+		expr = strings.TrimSpace(expr[i:])
+	}
+
+	desc := l.describeCron(expr)
+	if zone != "" {
+		desc = fmt.Sprintf(l.zone, desc, zone)
+	}
+	return desc
+}
+
+func (l *cronLocale) describeCron(expr string) string {
+	if desc, ok := l.descriptors[expr]; ok {
+		return desc
+	}
+	if strings.HasPrefix(expr, "@every ") {
+		return fmt.Sprintf(l.interval, strings.TrimSpace(expr[len("@every "):]))
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return expr
+	}
+	second, minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	// date part
+	var date string
+	switch {
+	case !isAny(dom) && !isAny(dow):
+		// a day matches if either field matches
+		date = fmt.Sprintf(l.either, l.describe(fieldDom, dom), l.describe(fieldDow, dow))
+	case !isAny(dom):
+		date = l.describe(fieldDom, dom)
+	case !isAny(dow):
+		date = l.describe(fieldDow, dow)
+	}
+	if !isAny(month) {
+		if date == "" {
+			date = l.describe(fieldMonth, month)
+		} else {
+			date = fmt.Sprintf(l.monthDate, l.describe(fieldMonth, month), date)
+		}
+	} else if !isAny(dom) {
+		date = fmt.Sprintf(l.monthly, date)
+	}
+
+	// time part
+	if isNumber(second) && isNumber(minute) && isNumber(hour) {
+		h, _ := strconv.Atoi(hour)
+		m, _ := strconv.Atoi(minute)
+		s, _ := strconv.Atoi(second)
+		if date == "" {
+			date = l.daily
+		}
+		return fmt.Sprintf(l.at, date, h, m, s)
+	}
+
+	var parts []string
+	if date == "" && !isAny(hour) && !isStep(hour) {
+		date = l.daily
+	}
+	if date != "" {
+		parts = append(parts, date)
+	}
+	// a wildcard field is implied by a finer field which is a wildcard or step
+	if !isAny(hour) || !(isAny(minute) || isStep(minute)) {
+		parts = append(parts, l.describe(fieldHour, hour))
+	}
+	if !isAny(minute) || !(isAny(second) || isStep(second)) {
+		parts = append(parts, l.describe(fieldMinute, minute))
+	}
+	if second != "0" || len(parts) == 0 {
+		parts = append(parts, l.describe(fieldSecond, second))
+	}
+	return strings.Join(parts, l.join)
+}
+
+// describe returns description of field value, e.g. 1-5, */10, 1,15.
+func (l *cronLocale) describe(field int, value string) string {
+	items := strings.Split(value, ",")
+	descs := make([]string, len(items))
+	for i, item := range items {
+		descs[i] = l.describeItem(field, item)
+	}
+	return strings.Join(descs, l.sep)
+}
+
+func (l *cronLocale) describeItem(field int, item string) string {
+	base, step := item, ""
+	if i := strings.Index(item, "/"); i >= 0 {
+		base, step = item[:i], item[i+1:]
+	}
+
+	w := &l.fields[field]
+	var desc string
+	switch {
+	case isAny(base):
+		if step == "" {
+			return w.every
+		}
+		return fmt.Sprintf(l.step, step, w.span)
+	case strings.Contains(base, "-"):
+		i := strings.Index(base, "-")
+		desc = fmt.Sprintf(l.between, l.name(field, base[:i]), l.name(field, base[i+1:]))
+	default:
+		desc = l.name(field, base)
+		if step != "" {
+			// a single start value with step means start-max/step
+			desc = fmt.Sprintf(l.from, desc)
+		}
+	}
+	if step != "" {
+		desc = fmt.Sprintf(l.stepOf, desc, fmt.Sprintf(l.step, step, w.span))
+	}
+	return desc
+}
+
+func (l *cronLocale) name(field int, value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		v, ok := aliases[field][strings.ToLower(value)]
+		if !ok {
+			return value
+		}
+		n = v
+	}
+	if names := l.fields[field].names; names != nil {
+		return names[n%len(names)]
+	}
+	return fmt.Sprintf(l.fields[field].point, n)
+}
+
+func isAny(value string) bool {
+	return value == "*" || value == "?"
+}
+
+func isStep(value string) bool {
+	return strings.HasPrefix(value, "*/")
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
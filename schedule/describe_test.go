@@ -0,0 +1,42 @@
+package schedule
+
+import "testing"
+
+func TestDescribeTrigger(t *testing.T) {
+	cases := []struct {
+		expr string
+		zh   string
+		en   string
+	}{
+		{"@daily", "每天 00:00:00", "at 00:00:00 every day"},
+		{"@every 1h30m", "每隔 1h30m", "every 1h30m"},
+		{"0 30 9 * * *", "每天 09:30:00", "every day at 09:30:00"},
+		{"0 0 9 * * 1-5", "周一至周五 09:00:00", "Monday to Friday at 09:00:00"},
+		{"0 */10 * * * *", "每隔 10 分钟", "every 10 minutes"},
+		{"*/5 * * * *", "每隔 5 分钟", "every 5 minutes"},
+		{"0 0 0 1,15 * *", "每月1日、15日 00:00:00", "day 1, day 15 of every month at 00:00:00"},
+		{"0 0 8 1 jan,jul *", "1月、7月1日 08:00:00", "day 1 in January, July at 08:00:00"},
+		{"0 0 0 1 * mon", "每月1日或周一 00:00:00", "day 1 or Monday of every month at 00:00:00"},
+		{"0 0 0 * 3 *", "3月 00:00:00", "March at 00:00:00"},
+		{"0 30 * * * *", "每小时 30分", "every hour; minute 30"},
+		{"0 0 10/2 * * *", "每天 从10时起每隔 2 小时 0分", "every day; from hour 10 every 2 hours; minute 0"},
+		{"0 0 9-17 * * MON-FRI", "周一至周五 9时至17时 0分", "Monday to Friday; hour 9 to hour 17; minute 0"},
+		{"* * * * * *", "每秒", "every second"},
+		{"TZ=Asia/Shanghai 0 0 9 * * *", "每天 09:00:00（Asia/Shanghai）", "every day at 09:00:00 (Asia/Shanghai)"},
+		{"invalid", "invalid", "invalid"},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			if got := DescribeTrigger(c.expr, "zh"); got != c.zh {
+				t.Errorf("zh: got %q, want %q", got, c.zh)
+			}
+			if got := DescribeTrigger(c.expr, "en"); got != c.en {
+				t.Errorf("en: got %q, want %q", got, c.en)
+			}
+			// Chinese is the default
+			if got := DescribeTrigger(c.expr, ""); got != c.zh {
+				t.Errorf("default: got %q, want %q", got, c.zh)
+			}
+		})
+	}
+}
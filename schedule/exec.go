@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"strings"
+
+	"github.com/cuigh/skynet/contract"
+)
+
+// execSchemes maps schemes of agent address to those of HTTP, so TLS options of HTTPCaller apply to `execs://`.
+var execSchemes = [][2]string{
+	{"execs://", "https://"},
+	{"exec://", "http://"},
+}
+
+// ExecCaller dispatches jobs to skynet-agent, which runs allowed shell commands for tasks whose runner is
+// `exec://host:port` or `execs://host:port`(HTTPS). Agent serves the same API as HTTP runners, so requests
+// are sent by HTTPCaller.
+type ExecCaller struct {
+	*HTTPCaller
+}
+
+func (c ExecCaller) Call(addrs []string, j *Job) *CallResult {
+	r := c.HTTPCaller.Call(httpAddrs(addrs), j)
+	if r.Address != "" {
+		r.Address = execAddr(r.Address)
+	}
+	return r
+}
+
+func (c ExecCaller) Cancel(addrs []string, id string) *CallResult {
+	return c.HTTPCaller.Cancel(httpAddrs(addrs), id)
+}
+
+func (c ExecCaller) Split(addrs []string, j *Job) *contract.SplitResult {
+	return &contract.SplitResult{Code: contract.CodeNotSupported, Info: "not supported"}
+}
+
+func httpAddrs(addrs []string) []string {
+	arr := make([]string, len(addrs))
+	for i, addr := range addrs {
+		arr[i] = replaceScheme(addr, 0, 1)
+	}
+	return arr
+}
+
+func execAddr(addr string) string {
+	return replaceScheme(addr, 1, 0)
+}
+
+func replaceScheme(addr string, from, to int) string {
+	for _, s := range execSchemes {
+		if strings.HasPrefix(addr, s[from]) {
+			return s[to] + addr[len(s[from]):]
+		}
+	}
+	return addr
+}
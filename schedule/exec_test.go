@@ -0,0 +1,23 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecAddrs(t *testing.T) {
+	addrs := httpAddrs([]string{"exec://127.0.0.1:8002", "execs://agent:8443", "http://127.0.0.1:8002"})
+	want := []string{"http://127.0.0.1:8002", "https://agent:8443", "http://127.0.0.1:8002"}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Fatalf("got %v, want %v", addrs, want)
+	}
+
+	for addr, want := range map[string]string{
+		"http://127.0.0.1:8002": "exec://127.0.0.1:8002",
+		"https://agent:8443":    "execs://agent:8443",
+	} {
+		if got := execAddr(addr); got != want {
+			t.Fatalf("execAddr(%s) = %s, want %s", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/store"
+)
+
+type fakeTaskStore struct {
+	store.TaskStore
+	modify time.Time
+	count  int64
+}
+
+func (s *fakeTaskStore) GetState() (time.Time, int64, error) {
+	return s.modify, s.count, nil
+}
+
+func (s *fakeTaskStore) FetchAll(enabled bool) ([]*store.Task, error) {
+	return nil, nil
+}
+
+func (s *fakeTaskStore) ResumeExpired(now time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeCalendarStore struct {
+	store.CalendarStore
+}
+
+func (s *fakeCalendarStore) GetState() (time.Time, int64, error) {
+	return time.Time{}, 0, nil
+}
+
+func (s *fakeCalendarStore) FetchMany(names []string) (map[string]*store.Calendar, error) {
+	return nil, nil
+}
+
+type fakeFireStore struct {
+	store.FireStore
+	lasts map[string]time.Time
+}
+
+func (s *fakeFireStore) Record(task string, fire time.Time) error {
+	if s.lasts == nil {
+		s.lasts = make(map[string]time.Time)
+	}
+	s.lasts[task] = fire
+	return nil
+}
+
+func (s *fakeFireStore) GetLastFireTimes(tasks []string) (map[string]time.Time, error) {
+	m := make(map[string]time.Time)
+	for _, t := range tasks {
+		if fire, ok := s.lasts[t]; ok {
+			m[t] = fire
+		}
+	}
+	return m, nil
+}
+
+func TestTaskFetcherLastFireTimes(t *testing.T) {
+	now := time.Now()
+	fs := &fakeFireStore{lasts: map[string]time.Time{"recorded": now}}
+	// jobs of tasks which fired long ago are expired
+	js := &fakeJobStore{lasts: map[string]time.Time{"recorded": now.Add(-time.Hour), "legacy": now.Add(-time.Minute)}}
+	f := NewTaskFetcher(nil, js, fs, nil, &Cluster{}, log.Get("schedule"))
+
+	tasks := []*store.Task{{Name: "recorded"}, {Name: "legacy"}, {Name: "never"}, {Name: "skip"}}
+	tasks[3].Misfire.Policy = store.MisfireSkip
+	for _, task := range tasks[:3] {
+		task.Misfire.Policy = store.MisfireFireOnce
+	}
+
+	lasts, err := f.lastFireTimes(tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// recorded fire times win, those of tasks without record are found from jobs
+	want := map[string]time.Time{"recorded": now, "legacy": now.Add(-time.Minute)}
+	if len(lasts) != len(want) {
+		t.Fatalf("got %v, want %v", lasts, want)
+	}
+	for name, fire := range want {
+		if !lasts[name].Equal(fire) {
+			t.Fatalf("got %v, want %v", lasts, want)
+		}
+	}
+}
+
+func TestTaskFetcherReload(t *testing.T) {
+	task := &store.Task{Name: "test", Triggers: []string{"0 * * * * *"}, Enabled: true}
+	paused := &store.Task{Name: "test", Triggers: []string{"0 * * * * *"}, Enabled: true, Pause: &store.PauseRecord{}}
+	disabled := &store.Task{Name: "test", Triggers: []string{"0 * * * * *"}}
+	invalid := &store.Task{Name: "test", Triggers: []string{"invalid"}, Enabled: true}
+
+	cases := []struct {
+		name   string
+		task   *store.Task
+		item   bool // change with item is expected
+		change bool // any change is expected
+	}{
+		{"modified", task, true, true},
+		{"deleted", nil, false, true},
+		{"paused", paused, false, true},
+		{"disabled", disabled, false, true},
+		{"invalid", invalid, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			modify := time.Now()
+			changes := make(chan *TaskChange, 1)
+			f := NewTaskFetcher(&fakeTaskStore{modify: modify, count: 1}, nil, nil, &fakeCalendarStore{}, &Cluster{}, log.Get("schedule"))
+			f.changes, f.loaded = changes, true
+
+			f.reload("test", c.task)
+			select {
+			case ch := <-changes:
+				if !c.change {
+					t.Fatal("unexpected change")
+				}
+				if ch.Name != "test" || (ch.Item != nil) != c.item {
+					t.Fatalf("unexpected change: %+v", ch)
+				}
+				if !f.modify.Equal(modify) || f.count != 1 {
+					t.Fatal("state of tasks should be updated")
+				}
+			default:
+				if c.change {
+					t.Fatal("change expected")
+				}
+				// invalid task makes polling rebuild the whole heap
+				if f.loaded {
+					t.Fatal("heap should be reloaded by polling")
+				}
+			}
+		})
+	}
+
+	t.Run("not loaded", func(t *testing.T) {
+		changes := make(chan *TaskChange, 1)
+		f := NewTaskFetcher(&fakeTaskStore{}, nil, nil, &fakeCalendarStore{}, &Cluster{}, log.Get("schedule"))
+		f.changes = changes
+
+		f.reload("test", task)
+		if len(changes) != 0 {
+			t.Fatal("changes before heap is loaded should be ignored")
+		}
+	})
+}
+
+func TestTaskFetcherRebalance(t *testing.T) {
+	c := &Cluster{node: "a", enabled: true}
+	heaps := make(chan *TaskHeap, 1)
+	f := NewTaskFetcher(&fakeTaskStore{}, nil, nil, &fakeCalendarStore{}, c, log.Get("schedule"))
+	f.heaps = heaps
+	loaded := func() bool {
+		select {
+		case <-heaps:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// heap is loaded before membership is fetched, e.g. heartbeat failed on startup
+	f.refresh(false)
+	if !loaded() {
+		t.Fatal("heap should be loaded")
+	}
+
+	f.refresh(false)
+	if loaded() {
+		t.Fatal("heap should not be rebuilt if nothing changed")
+	}
+
+	// membership is fetched at the first time
+	c.version++
+	f.refresh(false)
+	if !loaded() {
+		t.Fatal("heap should be rebuilt after membership changed")
+	}
+
+	f.refresh(false)
+	if loaded() {
+		t.Fatal("heap was loaded with current membership")
+	}
+}
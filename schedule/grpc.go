@@ -0,0 +1,182 @@
+package schedule
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/contract/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// grpcTimeout limits every request to runner, so a hung runner doesn't block dispatching forever.
+const grpcTimeout = 30 * time.Second
+
+// GRPCOptions are options of connections to gRPC runners, they are loaded from config `skynet.grpc.runner`.
+type GRPCOptions struct {
+	CA       string `option:"ca"`   // CA certificate file to verify runners of grpcs://host:port
+	Cert     string `option:"cert"` // client certificate file
+	Key      string `option:"key"`  // private key file of client certificate
+	Insecure bool   `option:"insecure"`
+	Token    string `option:"token"` // sent as `authorization: Bearer <token>`, runner verifies it by `skynet.runner.token`
+}
+
+// GRPCCaller dispatches jobs to runners serving pb.RunnerServer, format of runner: grpc://host:port, or
+// grpcs://host:port if runner serves with TLS.
+type GRPCCaller struct {
+	locker sync.Mutex
+	conns  map[string]*grpc.ClientConn // connections are shared among dispatches to the same runner
+	tls    credentials.TransportCredentials
+	token  string
+}
+
+// NewGRPCCaller creates GRPCCaller with options in config `skynet.grpc.runner`, it panics if options are invalid.
+func NewGRPCCaller() *GRPCCaller {
+	opts := &GRPCOptions{}
+	if config.Exist("skynet.grpc.runner") {
+		if err := config.UnmarshalOption("skynet.grpc.runner", opts); err != nil {
+			panic(errors.Wrap(err, "failed to load options of gRPC caller"))
+		}
+	}
+	cfg, err := contract.LoadTLS(opts.CA, opts.Cert, opts.Key, opts.Insecure)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load TLS options of gRPC caller"))
+	}
+
+	return &GRPCCaller{
+		conns: make(map[string]*grpc.ClientConn),
+		tls:   credentials.NewTLS(cfg),
+		token: opts.Token,
+	}
+}
+
+func (c *GRPCCaller) Call(addrs []string, j *Job) (r *CallResult) {
+	job := pb.NewJob(j.toContract())
+	for _, addr := range shuffle(addrs) {
+		r = c.call(addr, j.dispatch, func(ctx context.Context, client pb.RunnerClient) (*pb.Result, error) {
+			return client.Execute(ctx, job)
+		})
+		if r.Success() {
+			r.Address = addr
+			return
+		}
+		log.Get("schedule").Errorf("call with address '%s' failed: %s", addr, r.Info)
+	}
+	return
+}
+
+func (c *GRPCCaller) Split(addrs []string, j *Job) (r *contract.SplitResult) {
+	job := pb.NewJob(j.toContract())
+	for _, addr := range shuffle(addrs) {
+		client, err := c.client(addr)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutOr(j.dispatch, grpcTimeout))
+			var sr *pb.SplitResult
+			sr, err = client.Split(ctx, job)
+			cancel()
+			if err == nil {
+				r = sr.Contract()
+			}
+		}
+		if err != nil {
+			r = &contract.SplitResult{Code: contract.CodeFailed, Info: err.Error()}
+		}
+		if r.Code != contract.CodeFailed {
+			return
+		}
+		log.Get("schedule").Errorf("split with address '%s' failed: %s", addr, r.Info)
+	}
+	if r == nil {
+		r = &contract.SplitResult{Code: contract.CodeFailed, Info: "no available address"}
+	}
+	return
+}
+
+// Cancel sends cancel request to all addresses because runner which accepted the job is unknown.
+func (c *GRPCCaller) Cancel(addrs []string, id string) (r *CallResult) {
+	param := &pb.CancelParam{Id: id}
+	r = &CallResult{Code: 1, Info: "no available address"}
+	for _, addr := range addrs {
+		cr := c.call(addr, 0, func(ctx context.Context, client pb.RunnerClient) (*pb.Result, error) {
+			return client.Cancel(ctx, param)
+		})
+		if cr.Success() {
+			r = cr
+		} else {
+			log.Get("schedule").Errorf("cancel with address '%s' failed: %s", addr, cr.Info)
+			if !r.Success() {
+				r = cr
+			}
+		}
+	}
+	return
+}
+
+func (c *GRPCCaller) call(addr string, timeout time.Duration, fn func(ctx context.Context, client pb.RunnerClient) (*pb.Result, error)) *CallResult {
+	client, err := c.client(addr)
+	if err != nil {
+		return &CallResult{Code: 1, Info: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutOr(timeout, grpcTimeout))
+	defer cancel()
+
+	result, err := fn(ctx, client)
+	if err != nil {
+		return &CallResult{Code: 1, Info: err.Error()}
+	}
+	return &CallResult{Code: result.Code, Info: result.Info}
+}
+
+func (c *GRPCCaller) client(addr string) (pb.RunnerClient, error) {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+
+	conn := c.conns[addr]
+	if conn == nil {
+		// connection is established lazily and reconnected automatically
+		target, creds := strings.TrimPrefix(addr, "grpc://"), insecure.NewCredentials()
+		if strings.HasPrefix(addr, "grpcs://") {
+			target, creds = strings.TrimPrefix(addr, "grpcs://"), c.tls
+		}
+		opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+		if c.token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(pb.Token(c.token)))
+		}
+
+		var err error
+		conn, err = grpc.Dial(target, opts...)
+		if err != nil {
+			return nil, err
+		}
+		c.conns[addr] = conn
+	}
+	return pb.NewRunnerClient(conn), nil
+}
+
+// timeoutOr returns timeout if it is set, otherwise def.
+func timeoutOr(timeout, def time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	return def
+}
+
+func (j *Job) toContract() *contract.Job {
+	return &contract.Job{
+		Id:      j.Id,
+		Task:    j.Task,
+		Handler: j.Handler,
+		Args:    j.Args,
+		Mode:    j.Mode,
+		Fire:    j.Fire,
+		Attempt: j.Attempt,
+	}
+}
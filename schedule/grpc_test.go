@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"net"
+	"testing"
+
+	"github.com/cuigh/auxo/data"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/runner"
+)
+
+type splitHandler struct {
+	runner.HandlerFunc
+}
+
+func (splitHandler) Split(job *contract.Job) ([]*contract.Batch, error) {
+	if job.Args.Get("fail") != "" {
+		return nil, errors.New("split failed")
+	}
+	return []*contract.Batch{{Id: "1"}, {Id: "2"}}, nil
+}
+
+// startGRPCRunner serves registered handlers by gRPC and returns address of runner.
+func startGRPCRunner(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := runner.NewGRPCServer()
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	return "grpc://" + l.Addr().String()
+}
+
+func TestGRPCCaller(t *testing.T) {
+	runner.Register("test.split", splitHandler{})
+	addr := startGRPCRunner(t)
+	c := NewGRPCCaller()
+
+	r := c.Split([]string{addr}, &Job{Id: "1", Handler: "test.split"})
+	if r.Code != contract.CodeSuccess || len(r.Batches) != 2 || r.Batches[1].Id != "2" {
+		t.Fatalf("unexpected split result: %+v", r)
+	}
+	if r = c.Split([]string{addr}, &Job{Id: "1", Handler: "test.split", Args: data.Options{{Name: "fail", Value: "1"}}}); r.Code != contract.CodeFailed {
+		t.Fatalf("expected split to fail, got %+v", r)
+	}
+	if r = c.Split([]string{addr}, &Job{Id: "1", Handler: "test.missing"}); r.Code != contract.CodeNotFound {
+		t.Fatalf("expected CodeNotFound, got %+v", r)
+	}
+
+	if cr := c.Cancel([]string{addr}, "missing"); cr.Code != contract.CodeNotFound {
+		t.Fatalf("expected CodeNotFound for job which is not running, got %+v", cr)
+	}
+	// unreachable address fails
+	if cr := c.Cancel([]string{"grpc://127.0.0.1:1"}, "missing"); cr.Success() {
+		t.Fatal("cancel with unreachable address should fail")
+	}
+}
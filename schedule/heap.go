@@ -3,36 +3,194 @@ package schedule
 import (
 	"time"
 
+	"github.com/cuigh/auxo/errors"
 	"github.com/cuigh/auxo/log"
 	"github.com/cuigh/skynet/store"
 	"github.com/robfig/cron/v3"
 )
 
-var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+// maxSkips limits how many excluded fire times are skipped in a search, it avoids endless loop on calendars
+// which exclude all fire times.
+const maxSkips = 1000
 
 // An TaskItem is something we manage in a priority queue.
 type TaskItem struct {
-	fire     time.Time
-	task     *store.Task
-	triggers []cron.Schedule
+	fire      time.Time
+	dueTime   time.Time // dispatch time of fire, it is cached since heap compares it frequently
+	last      time.Time // fire time of last dispatched job
+	missed    int32     // count of missed fires dispatched in current catch-up
+	task      *store.Task
+	triggers  []cron.Schedule
+	delay     time.Duration // delay after previous job ends for fixed-delay task
+	jitter    time.Duration // max delay of dispatching after fire time
+	calendars []*calendar
 }
 
-func NewItem(task *store.Task) (*TaskItem, error) {
+// NewItem creates a TaskItem, calendars must contain all calendars referenced by task.
+func NewItem(task *store.Task, calendars map[string]*store.Calendar) (*TaskItem, error) {
 	item := &TaskItem{
-		task: task,
+		task:   task,
+		jitter: time.Duration(task.Trigger.Jitter) * time.Second,
 	}
-	for _, c := range task.Triggers {
-		t, err := cronParser.Parse(c)
+	if item.jitter < 0 {
+		return nil, errors.Format("invalid jitter of task '%s': %d", task.Name, task.Trigger.Jitter)
+	}
+
+	for _, name := range task.Calendars {
+		c, ok := calendars[name]
+		if !ok {
+			return nil, errors.Format("calendar '%s' of task '%s' is not found", name, task.Name)
+		}
+		cal, err := newCalendar(c)
 		if err != nil {
 			return nil, err
 		}
-		item.triggers = append(item.triggers, t)
+		item.calendars = append(item.calendars, cal)
+	}
+
+	trigger := task.Trigger
+	switch trigger.Type {
+	case store.TriggerCron:
+		loc, err := LoadLocation(task.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range task.Triggers {
+			t, err := ParseTrigger(c, loc, task.Name)
+			if err != nil {
+				return nil, err
+			}
+			item.triggers = append(item.triggers, t)
+		}
+	case store.TriggerInterval:
+		if trigger.Interval <= 0 {
+			return nil, errors.Format("invalid interval of task '%s': %d", task.Name, trigger.Interval)
+		}
+		anchor := time.Unix(0, 0)
+		if trigger.Anchor != nil {
+			anchor = time.Time(*trigger.Anchor)
+		}
+		item.triggers = append(item.triggers, intervalSchedule{anchor: anchor, every: time.Duration(trigger.Interval) * time.Second})
+	case store.TriggerDelay:
+		if trigger.Interval <= 0 {
+			return nil, errors.Format("invalid delay of task '%s': %d", task.Name, trigger.Interval)
+		}
+		item.delay = time.Duration(trigger.Interval) * time.Second
+	case store.TriggerOnce:
+		if trigger.At == nil {
+			return nil, errors.Format("fire time of task '%s' is missing", task.Name)
+		}
+		item.triggers = append(item.triggers, onceSchedule(*trigger.At))
+	default:
+		return nil, errors.Format("invalid trigger type of task '%s': %d", task.Name, trigger.Type)
 	}
 	return item, nil
 }
 
-// update fire time
-func (i *TaskItem) next(start time.Time) {
+// NextFireTime returns the first fire time of task after start, it is zero if task will never fire.
+func NextFireTime(task *store.Task, calendars map[string]*store.Calendar, start time.Time) (time.Time, error) {
+	fires, err := NextFireTimes(task, calendars, start, 1)
+	if err != nil || len(fires) == 0 {
+		return time.Time{}, err
+	}
+	return fires[0], nil
+}
+
+// NextFireTimes returns at most n fire times of task after start.
+func NextFireTimes(task *store.Task, calendars map[string]*store.Calendar, start time.Time, n int) ([]time.Time, error) {
+	if task.Trigger.Type == store.TriggerDelay {
+		return nil, errors.New("fire time of fixed-delay task depends on previous job")
+	}
+
+	item, err := NewItem(task, calendars)
+	if err != nil {
+		return nil, err
+	}
+
+	var fires []time.Time
+	for len(fires) < n {
+		fire := item.after(start)
+		if !fire.Before(start.AddDate(100, 0, 0)) {
+			// no more fire times
+			break
+		}
+		fires = append(fires, fire)
+		start = fire
+	}
+	return fires, nil
+}
+
+// update fire time, fires missed between last fire and now are handled by misfire policy of task
+func (i *TaskItem) next(now time.Time) {
+	if i.last.IsZero() {
+		i.missed = 0
+		i.setFire(i.after(now))
+		return
+	}
+
+	fire := i.after(i.last)
+	if fire.After(now) {
+		i.missed = 0
+		i.setFire(fire)
+		return
+	}
+
+	switch i.task.Misfire.Policy {
+	case store.MisfireFireOnce:
+		if i.missed == 0 {
+			i.missed = 1
+			i.setFire(fire)
+			return
+		}
+	case store.MisfireFireAll:
+		if limit := i.task.Misfire.Limit; limit <= 0 || i.missed < limit {
+			i.missed++
+			i.setFire(fire)
+			return
+		}
+	}
+	i.missed = 0
+	i.setFire(i.after(now))
+}
+
+// resume schedules next fire of fixed-delay task after previous job ended at end.
+func (i *TaskItem) resume(end time.Time) {
+	i.setFire(i.skip(end.Add(i.delay)))
+}
+
+// setFire changes current fire time and computes its dispatch time, which is delayed by jitter of task.
+func (i *TaskItem) setFire(fire time.Time) {
+	i.fire, i.dueTime = fire, fire
+	if i.jitter > 0 {
+		i.dueTime = fire.Add(jitter(i.task.Name, fire, i.jitter))
+	}
+}
+
+// due returns when current fire should be dispatched.
+func (i *TaskItem) due() time.Time {
+	return i.dueTime
+}
+
+// dispatched marks current fire time as dispatched.
+func (i *TaskItem) dispatched() {
+	i.last = i.fire
+}
+
+// return the earliest fire time after start which is not excluded by calendars
+func (i *TaskItem) after(start time.Time) time.Time {
+	fire := i.earliest(start)
+	for n := 0; n < maxSkips; n++ {
+		end := i.block(fire)
+		if end.IsZero() {
+			return fire
+		}
+		fire = i.earliest(end.Add(-time.Nanosecond))
+	}
+	return start.AddDate(100, 0, 0)
+}
+
+// return the earliest fire time after start
+func (i *TaskItem) earliest(start time.Time) time.Time {
 	var fire time.Time
 	for _, t := range i.triggers {
 		next := t.Next(start)
@@ -44,7 +202,29 @@ func (i *TaskItem) next(start time.Time) {
 		// avoid endless loop
 		fire = start.AddDate(100, 0, 0)
 	}
-	i.fire = fire
+	return fire
+}
+
+// skip returns the earliest time from t which is not excluded by calendars, it is used by fixed-delay task.
+func (i *TaskItem) skip(t time.Time) time.Time {
+	for n := 0; n < maxSkips; n++ {
+		end := i.block(t)
+		if end.IsZero() {
+			return t
+		}
+		t = end
+	}
+	return t.AddDate(100, 0, 0)
+}
+
+// block returns the latest end of exclusions which t falls inside, it returns zero time if t is not excluded.
+func (i *TaskItem) block(t time.Time) (end time.Time) {
+	for _, c := range i.calendars {
+		if e := c.block(t); e.After(end) {
+			end = e
+		}
+	}
+	return
 }
 
 // A TaskHeap implements minimum heap and holds tasks.
@@ -52,18 +232,18 @@ type TaskHeap struct {
 	items []*TaskItem
 }
 
-func NewTaskHeap(tasks []*store.Task) *TaskHeap {
+// NewTaskHeap creates a TaskHeap, lasts holds fire time of last dispatched job for tasks which need misfire handling,
+// ends holds end time of last job for fixed-delay tasks, it is zero if the job is not finished yet.
+func NewTaskHeap(tasks []*store.Task, calendars map[string]*store.Calendar, lasts, ends map[string]time.Time) *TaskHeap {
 	now := time.Now()
-	items := make([]*TaskItem, len(tasks))
-	for i, task := range tasks {
-		item, err := NewItem(task)
+	items := make([]*TaskItem, 0, len(tasks))
+	for _, task := range tasks {
+		item, err := newHeapItem(task, calendars, lasts, ends, now)
 		if err != nil {
 			log.Get("schedule").Errorf("failed to create TaskItem: %s", err)
 			continue
 		}
-
-		item.next(now)
-		items[i] = item
+		items = append(items, item)
 	}
 
 	h := &TaskHeap{items: items}
@@ -71,6 +251,94 @@ func NewTaskHeap(tasks []*store.Task) *TaskHeap {
 	return h
 }
 
+// newHeapItem creates a TaskItem with its first fire time, see NewTaskHeap for lasts and ends.
+func newHeapItem(task *store.Task, calendars map[string]*store.Calendar, lasts, ends map[string]time.Time, now time.Time) (*TaskItem, error) {
+	item, err := NewItem(task, calendars)
+	if err != nil {
+		return nil, err
+	}
+
+	if last, ok := lasts[task.Name]; ok && task.Misfire.Policy != store.MisfireSkip {
+		// fires before task was modified are not treated as misfires
+		if modify := time.Time(task.ModifyTime); last.Before(modify) {
+			last = modify
+		}
+		item.last = last
+	}
+	item.next(now)
+	if item.delay > 0 {
+		// fire immediately if task never ran(modify time is used so that all nodes get the same fire time),
+		// or wait until last job is finished
+		if end, ok := ends[task.Name]; !ok {
+			item.setFire(item.skip(time.Time(task.ModifyTime)))
+		} else if !end.IsZero() {
+			item.resume(end)
+		}
+	}
+	return item, nil
+}
+
+// inherit copies last fire times from old heap for tasks which need misfire handling.
+func (h *TaskHeap) inherit(old *TaskHeap) {
+	lasts := make(map[string]time.Time)
+	for _, item := range old.items {
+		if !item.last.IsZero() {
+			lasts[item.task.Name] = item.last
+		}
+	}
+
+	now := time.Now()
+	for _, item := range h.items {
+		if item.task.Misfire.Policy == store.MisfireSkip || item.delay > 0 {
+			continue
+		}
+		if last, ok := lasts[item.task.Name]; ok && last.After(item.last) {
+			item.last = last
+			item.next(now)
+		}
+	}
+	h.init()
+}
+
+// replace replaces item of task with name by item, or removes it if item is nil,
+// item is added if task doesn't exist in heap. Last fire time of old item is inherited like inherit.
+func (h *TaskHeap) replace(name string, item *TaskItem) {
+	for i, old := range h.items {
+		if old.task.Name != name {
+			continue
+		}
+
+		if item == nil {
+			h.remove(i)
+			return
+		}
+		if item.task.Misfire.Policy != store.MisfireSkip && item.delay == 0 && old.last.After(item.last) {
+			item.last = old.last
+			item.next(time.Now())
+		}
+		h.items[i] = item
+		h.Update(i)
+		return
+	}
+
+	if item != nil {
+		h.Push(item)
+	}
+}
+
+// resume schedules next fire of fixed-delay task, it is ignored if task is not a fixed-delay one.
+func (h *TaskHeap) resume(name string, end time.Time) {
+	for i, item := range h.items {
+		if item.task.Name == name {
+			if item.delay > 0 {
+				item.resume(end)
+				h.Update(i)
+			}
+			return
+		}
+	}
+}
+
 func (h *TaskHeap) Count() int { return len(h.items) }
 
 func (h *TaskHeap) Push(item *TaskItem) {
@@ -97,6 +365,18 @@ func (h *TaskHeap) Pop() *TaskItem {
 	return item
 }
 
+func (h *TaskHeap) remove(i int) {
+	n := len(h.items) - 1
+	if i != n {
+		h.swap(i, n)
+	}
+	h.items[n] = nil
+	h.items = h.items[:n]
+	if i < n {
+		h.Update(i)
+	}
+}
+
 func (h *TaskHeap) Update(i int) {
 	if !h.down(i, h.Count()) {
 		h.up(i)
@@ -111,7 +391,7 @@ func (h *TaskHeap) init() {
 }
 
 func (h *TaskHeap) less(i, j int) bool {
-	return h.items[i].fire.Before(h.items[j].fire)
+	return h.items[i].due().Before(h.items[j].due())
 }
 
 func (h *TaskHeap) swap(i, j int) {
@@ -0,0 +1,238 @@
+package schedule
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/store"
+)
+
+func newIntervalTask(name string, seconds int32, policy, limit int32) *store.Task {
+	t := &store.Task{Name: name}
+	t.Trigger.Type = store.TriggerInterval
+	t.Trigger.Interval = seconds
+	t.Misfire.Policy = policy
+	t.Misfire.Limit = limit
+	return t
+}
+
+func TestTaskItemMisfire(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes ...int) []time.Time {
+		var ts []time.Time
+		for _, m := range minutes {
+			ts = append(ts, base.Add(time.Duration(m)*time.Minute))
+		}
+		return ts
+	}
+
+	cases := []struct {
+		name   string
+		policy int32
+		limit  int32
+		last   time.Time
+		fires  []time.Time
+	}{
+		{"skip", store.MisfireSkip, 0, base, at(6, 7)},
+		{"fire once", store.MisfireFireOnce, 0, base, at(1, 6, 7)},
+		{"fire all", store.MisfireFireAll, 0, base, at(1, 2, 3, 4, 5, 6, 7)},
+		{"fire all with limit", store.MisfireFireAll, 2, base, at(1, 2, 6, 7)},
+		{"nothing missed", store.MisfireFireAll, 0, base.Add(5 * time.Minute), at(6, 7)},
+		{"never fired", store.MisfireFireAll, 0, time.Time{}, at(6, 7)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			item, err := NewItem(newIntervalTask("test", 60, c.policy, c.limit), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			item.last = c.last
+
+			now := base.Add(5*time.Minute + 30*time.Second)
+			for i, want := range c.fires {
+				item.next(now)
+				if !item.fire.Equal(want) {
+					t.Fatalf("fire %d: got %s, want %s", i, item.fire, want)
+				}
+				item.dispatched()
+				if item.fire.After(now) {
+					// fires in future are dispatched on time
+					now = item.fire
+				}
+			}
+		})
+	}
+}
+
+func TestNewTaskHeapMisfire(t *testing.T) {
+	now := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+	last := now.Add(-5*time.Minute - 30*time.Second)
+
+	modified := newIntervalTask("modified", 60, store.MisfireFireAll, 0)
+	modified.ModifyTime = store.Time(now.Add(-2 * time.Minute))
+
+	cases := []struct {
+		name string
+		task *store.Task
+		want time.Time
+	}{
+		{"skip", newIntervalTask("skip", 60, store.MisfireSkip, 0), now.Add(30 * time.Second)},
+		{"fire once", newIntervalTask("once", 60, store.MisfireFireOnce, 0), last.Add(time.Minute)},
+		// fires before task was modified are not treated as misfires
+		{"modified", modified, now.Add(-90 * time.Second)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewTaskHeap([]*store.Task{c.task}, nil, map[string]time.Time{c.task.Name: last}, nil)
+			if got := h.Peek().fire; !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewTaskHeapFixedDelay(t *testing.T) {
+	modify := time.Now().Add(-time.Hour)
+	end := time.Now().Add(-30 * time.Second)
+	newDelayTask := func(name string) *store.Task {
+		task := &store.Task{Name: name, ModifyTime: store.Time(modify)}
+		task.Trigger.Type = store.TriggerDelay
+		task.Trigger.Interval = 60
+		return task
+	}
+
+	cases := []struct {
+		name string
+		ends map[string]time.Time
+		want time.Time
+	}{
+		// fire immediately with modify time so that all nodes get the same fire time
+		{"never ran", nil, modify},
+		{"last job ended", map[string]time.Time{"test": end}, end.Add(time.Minute)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewTaskHeap([]*store.Task{newDelayTask("test")}, nil, nil, c.ends)
+			if got := h.Peek().fire; !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+
+	t.Run("last job is running", func(t *testing.T) {
+		h := NewTaskHeap([]*store.Task{newDelayTask("test")}, nil, nil, map[string]time.Time{"test": {}})
+		if fire := h.Peek().fire; fire.Before(time.Now().AddDate(1, 0, 0)) {
+			t.Fatalf("task should wait for running job, got fire time %s", fire)
+		}
+
+		h.resume("test", end)
+		if got, want := h.Peek().fire, end.Add(time.Minute); !got.Equal(want) {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	})
+}
+
+func TestNextFireTimes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	at := store.Time(start.Add(time.Hour))
+
+	interval := newIntervalTask("interval", 60, store.MisfireSkip, 0)
+	once := &store.Task{Name: "once"}
+	once.Trigger.Type = store.TriggerOnce
+	once.Trigger.At = &at
+	delay := &store.Task{Name: "delay"}
+	delay.Trigger.Type = store.TriggerDelay
+	delay.Trigger.Interval = 60
+
+	cases := []struct {
+		name  string
+		task  *store.Task
+		fires []time.Time
+		err   bool
+	}{
+		{"interval", interval, []time.Time{start.Add(30 * time.Second), start.Add(90 * time.Second), start.Add(150 * time.Second)}, false},
+		{"once", once, []time.Time{time.Time(at)}, false},
+		{"delay", delay, nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fires, err := NextFireTimes(c.task, nil, start, 3)
+			if (err != nil) != c.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(fires) != len(c.fires) {
+				t.Fatalf("got %v, want %v", fires, c.fires)
+			}
+			for i := range fires {
+				if !fires[i].Equal(c.fires[i]) {
+					t.Fatalf("fire %d: got %s, want %s", i, fires[i], c.fires[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskHeapReplace(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	newItem := func(name string, seconds int32, policy int32) *TaskItem {
+		item, err := NewItem(newIntervalTask(name, seconds, policy, 0), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.next(now)
+		return item
+	}
+
+	h := &TaskHeap{}
+	h.Push(newItem("a", 60, store.MisfireSkip))
+	h.Push(newItem("b", 120, store.MisfireFireAll))
+
+	// add
+	h.replace("c", newItem("c", 10, store.MisfireSkip))
+	if h.Count() != 3 || h.Peek().task.Name != "c" {
+		t.Fatalf("task c should be added and fire first, got %d items, first %s", h.Count(), h.Peek().task.Name)
+	}
+
+	// modify, last fire time of old item is inherited
+	for _, item := range h.items {
+		if item.task.Name == "b" {
+			item.last = now.Add(-time.Hour)
+		}
+	}
+	h.replace("b", newItem("b", 5, store.MisfireFireAll))
+	if first := h.Peek(); first.task.Name != "b" || !first.last.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("task b should be replaced and keep its last fire time, got %s, last %s", first.task.Name, first.last)
+	}
+
+	// remove
+	h.replace("b", nil)
+	h.replace("missing", nil)
+	if h.Count() != 2 || h.Peek().task.Name != "c" {
+		t.Fatalf("task b should be removed, got %d items, first %s", h.Count(), h.Peek().task.Name)
+	}
+}
+
+func TestTaskHeapOrdersByDue(t *testing.T) {
+	h := &TaskHeap{}
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		task := &store.Task{Name: "task-" + strconv.Itoa(i), TimeZone: "UTC", Triggers: []string{"0 * * * * *"}}
+		task.Trigger.Jitter = 120
+		item, err := NewItem(task, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.next(now)
+		h.Push(item)
+	}
+
+	var last time.Time
+	for h.Count() > 0 {
+		item := h.Pop()
+		if item.due().Before(last) {
+			t.Fatalf("task %s is due at %s, before %s", item.task.Name, item.due(), last)
+		}
+		last = item.due()
+	}
+}
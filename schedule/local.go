@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"github.com/cuigh/auxo/ext/times"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/runner"
+)
+
+// LocalCaller invokes handlers registered by runner package in current process directly for tasks whose runner
+// is `local://`, so Skynet can run as a single binary. Jobs are recorded and notified the same as remote ones.
+type LocalCaller struct {
+	s *Scheduler
+}
+
+func (c LocalCaller) Call(addrs []string, j *Job) *CallResult {
+	r := runner.Execute(j.toContract(), c)
+	// job can only be cancelled on the node which executed it
+	return &CallResult{Code: r.Code, Info: r.Info, Address: "local://" + c.s.node}
+}
+
+func (c LocalCaller) Cancel(addrs []string, id string) *CallResult {
+	r := runner.Cancel(id)
+	return &CallResult{Code: r.Code, Info: r.Info}
+}
+
+func (c LocalCaller) Split(addrs []string, j *Job) *contract.SplitResult {
+	return runner.Split(j.toContract())
+}
+
+// Notify implements runner.Reporter.
+func (c LocalCaller) Notify(param contract.NotifyParam) error {
+	start, end := times.FromUnixMilli(param.Start), times.FromUnixMilli(param.End)
+	return c.s.Notify(param.Id, param.Attempt, param.Code, param.Info, start, end)
+}
+
+// Heartbeat implements runner.Reporter.
+func (c LocalCaller) Heartbeat(param contract.HeartbeatParam) error {
+	return c.s.Heartbeat(param.Id, param.Runner)
+}
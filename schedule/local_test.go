@@ -0,0 +1,24 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/runner"
+)
+
+func TestLocalCaller(t *testing.T) {
+	runner.Register("test.local.split", splitHandler{})
+	c := LocalCaller{s: &Scheduler{node: "node1"}}
+
+	r := c.Split(nil, &Job{Id: "1", Handler: "test.local.split"})
+	if r.Code != contract.CodeSuccess || len(r.Batches) != 2 {
+		t.Fatalf("unexpected split result: %+v", r)
+	}
+	if r = c.Split(nil, &Job{Id: "1", Handler: "test.missing"}); r.Code != contract.CodeNotFound {
+		t.Fatalf("expected CodeNotFound, got %+v", r)
+	}
+	if cr := c.Cancel(nil, "missing"); cr.Code != contract.CodeNotFound {
+		t.Fatalf("expected CodeNotFound for job which is not running, got %+v", cr)
+	}
+}
@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/log"
+)
+
+const (
+	PoolPolicyBlock = "block" // wait until queue has room, it slows down the scheduler loop
+	PoolPolicyDrop  = "drop"  // discard the job
+	PoolPolicySpawn = "spawn" // dispatch the job in a new goroutine, bypassing the pool
+)
+
+type poolTask struct {
+	job   *Job
+	retry bool
+}
+
+// Pool dispatches jobs by a fixed count of workers, so bursts of fires don't create unbounded goroutines,
+// dispatches to the same runner are limited too. Jobs of a runner which reached the limit wait aside without
+// occupying workers, so they don't block jobs of other runners.
+type Pool struct {
+	queue   chan *poolTask
+	workers int
+	limit   int // max concurrent dispatches per runner, 0 means unlimited
+	policy  string
+	handler func(job *Job, retry bool)
+	locker  sync.Mutex
+	running map[string]int         // count of dispatches of each runner
+	waiting map[string][]*poolTask // jobs waiting for runners which reached the limit
+	closer  <-chan struct{}
+	logger  log.Logger
+
+	busy    int64 // count of workers which are dispatching
+	dropped int64 // count of jobs discarded because queue was full
+	lag     int64 // max delay in milliseconds from fire time to dispatching since last Stats
+}
+
+func NewPool(handler func(job *Job, retry bool), logger log.Logger) *Pool {
+	workers := config.GetInt("skynet.dispatch.workers")
+	if workers <= 0 {
+		workers = 64
+	}
+	size := config.GetInt("skynet.dispatch.queue")
+	if size <= 0 {
+		size = 10000
+	}
+	policy := config.GetString("skynet.dispatch.policy")
+	if policy == "" {
+		policy = PoolPolicyBlock
+	}
+	return &Pool{
+		queue:   make(chan *poolTask, size),
+		workers: workers,
+		limit:   config.GetInt("skynet.dispatch.runner_limit"),
+		policy:  policy,
+		handler: handler,
+		running: make(map[string]int),
+		waiting: make(map[string][]*poolTask),
+		logger:  logger,
+	}
+}
+
+// Start starts workers, they exit after closer is closed.
+func (p *Pool) Start(closer <-chan struct{}) {
+	p.closer = closer
+	for i := 0; i < p.workers; i++ {
+		go p.work(closer)
+	}
+}
+
+// Submit puts job into queue, it returns false if job was dropped.
+func (p *Pool) Submit(job *Job, retry bool) bool {
+	t := &poolTask{job: job, retry: retry}
+	select {
+	case p.queue <- t:
+		return true
+	default:
+	}
+
+	switch p.policy {
+	case PoolPolicyDrop:
+		atomic.AddInt64(&p.dropped, 1)
+		p.logger.Errorf("dispatch queue is full, job '%s' of task '%s' is dropped", job.Id, job.Task)
+		return false
+	case PoolPolicySpawn:
+		p.logger.Warnf("dispatch queue is full, job '%s' of task '%s' is dispatched out of pool", job.Id, job.Task)
+		go p.run(t)
+		return true
+	default:
+		select {
+		case p.queue <- t:
+		case <-p.closer:
+			// workers are exiting, let handler deal with it in current goroutine
+			p.handler(job, retry)
+		}
+		return true
+	}
+}
+
+// Drain passes jobs left in queue to handler in current goroutine, it is called after workers exited.
+func (p *Pool) Drain() {
+	p.locker.Lock()
+	var tasks []*poolTask
+	for runner, ts := range p.waiting {
+		tasks = append(tasks, ts...)
+		delete(p.waiting, runner)
+	}
+	p.locker.Unlock()
+
+	for _, t := range tasks {
+		p.handler(t.job, t.retry)
+	}
+	for {
+		select {
+		case t := <-p.queue:
+			p.handler(t.job, t.retry)
+		default:
+			return
+		}
+	}
+}
+
+// Stats returns count of queued jobs, count of busy workers, count of dropped jobs and max dispatch lag since last call.
+func (p *Pool) Stats() (queued, busy int, dropped int64, lag time.Duration) {
+	p.locker.Lock()
+	for _, ts := range p.waiting {
+		queued += len(ts)
+	}
+	p.locker.Unlock()
+
+	lag = time.Duration(atomic.SwapInt64(&p.lag, 0)) * time.Millisecond
+	return queued + len(p.queue), int(atomic.LoadInt64(&p.busy)), atomic.LoadInt64(&p.dropped), lag
+}
+
+func (p *Pool) work(closer <-chan struct{}) {
+	for {
+		select {
+		case t := <-p.queue:
+			p.run(t)
+		case <-closer:
+			return
+		}
+	}
+}
+
+func (p *Pool) run(t *poolTask) {
+	if !p.acquire(t) {
+		return
+	}
+
+	// a waiting job of the same runner takes over the slot when current one is finished
+	for t != nil {
+		p.execute(t)
+		t = p.release(t.job.runner)
+	}
+}
+
+func (p *Pool) execute(t *poolTask) {
+	atomic.AddInt64(&p.busy, 1)
+	defer atomic.AddInt64(&p.busy, -1)
+
+	if !t.retry {
+		p.record(time.Since(t.job.fire) - t.job.jitter)
+	}
+	p.handler(t.job, t.retry)
+}
+
+// acquire takes a dispatch slot of runner, job is put aside to wait if runner reached the limit.
+func (p *Pool) acquire(t *poolTask) bool {
+	if p.limit <= 0 {
+		return true
+	}
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	runner := t.job.runner
+	if p.running[runner] < p.limit {
+		p.running[runner]++
+		return true
+	}
+	p.waiting[runner] = append(p.waiting[runner], t)
+	return false
+}
+
+// release frees a dispatch slot of runner, it returns the next waiting job of runner which takes over the slot.
+func (p *Pool) release(runner string) *poolTask {
+	if p.limit <= 0 {
+		return nil
+	}
+
+	p.locker.Lock()
+	defer p.locker.Unlock()
+
+	if ts := p.waiting[runner]; len(ts) > 0 {
+		if len(ts) == 1 {
+			delete(p.waiting, runner)
+		} else {
+			p.waiting[runner] = ts[1:]
+		}
+		return ts[0]
+	}
+	if p.running[runner]--; p.running[runner] == 0 {
+		delete(p.running, runner)
+	}
+	return nil
+}
+
+func (p *Pool) record(lag time.Duration) {
+	ms := lag.Milliseconds()
+	for {
+		old := atomic.LoadInt64(&p.lag)
+		if ms <= old || atomic.CompareAndSwapInt64(&p.lag, old, ms) {
+			return
+		}
+	}
+}
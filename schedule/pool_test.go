@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/contract"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestPool(workers, size, limit int, policy string, handler func(job *Job, retry bool)) *Pool {
+	return &Pool{
+		queue:   make(chan *poolTask, size),
+		workers: workers,
+		limit:   limit,
+		policy:  policy,
+		handler: handler,
+		running: make(map[string]int),
+		waiting: make(map[string][]*poolTask),
+		logger:  log.Get("schedule"),
+	}
+}
+
+func TestPoolDrop(t *testing.T) {
+	p := newTestPool(1, 1, 0, PoolPolicyDrop, func(job *Job, retry bool) {})
+
+	// workers are not started, so the queue is full after the first job
+	if !p.Submit(&Job{Id: "1"}, false) {
+		t.Fatal("job should be queued")
+	}
+	if p.Submit(&Job{Id: "2"}, false) {
+		t.Fatal("job should be dropped")
+	}
+	if queued, _, dropped, _ := p.Stats(); queued != 1 || dropped != 1 {
+		t.Fatalf("got queued %d, dropped %d", queued, dropped)
+	}
+}
+
+func TestPoolRunnerLimit(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		locker  sync.Mutex
+		running = make(map[string]int)
+		peak    = make(map[string]int)
+		count   int64
+	)
+	p := newTestPool(8, 100, 2, PoolPolicyBlock, func(job *Job, retry bool) {
+		defer wg.Done()
+
+		locker.Lock()
+		running[job.runner]++
+		if running[job.runner] > peak[job.runner] {
+			peak[job.runner] = running[job.runner]
+		}
+		locker.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt64(&count, 1)
+
+		locker.Lock()
+		running[job.runner]--
+		locker.Unlock()
+	})
+	closer := make(chan struct{})
+	defer close(closer)
+	p.Start(closer)
+
+	for i := 0; i < 20; i++ {
+		runner := "a"
+		if i%2 == 1 {
+			runner = "b"
+		}
+		wg.Add(1)
+		p.Submit(&Job{runner: runner, fire: time.Now()}, false)
+	}
+	wg.Wait()
+
+	if count := atomic.LoadInt64(&count); count != 20 {
+		t.Fatalf("expected 20 jobs to be dispatched, got %d", count)
+	}
+	for runner, n := range peak {
+		if n > 2 {
+			t.Fatalf("runner %s got %d concurrent dispatches", runner, n)
+		}
+	}
+}
+
+func TestPoolRunnerBusy(t *testing.T) {
+	var (
+		block = make(chan struct{})
+		done  = make(chan string, 3)
+	)
+	p := newTestPool(2, 10, 1, PoolPolicyBlock, func(job *Job, retry bool) {
+		if job.Id == "a1" {
+			<-block
+		}
+		done <- job.Id
+	})
+	closer := make(chan struct{})
+	defer close(closer)
+	p.Start(closer)
+
+	p.Submit(&Job{Id: "a1", runner: "a", fire: time.Now()}, false)
+	p.Submit(&Job{Id: "a2", runner: "a", fire: time.Now()}, false)
+	p.Submit(&Job{Id: "b1", runner: "b", fire: time.Now()}, false)
+
+	// a2 waits for a1 without occupying a worker, so b1 isn't blocked
+	select {
+	case id := <-done:
+		if id != "b1" {
+			t.Fatalf("expected b1 to be dispatched first, got %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("b1 is blocked by busy runner a")
+	}
+	if queued, _, _, _ := p.Stats(); queued != 1 {
+		t.Fatalf("expected a2 to be waiting, got %d queued jobs", queued)
+	}
+
+	close(block)
+	for _, want := range []string{"a1", "a2"} {
+		if id := <-done; id != want {
+			t.Fatalf("got %s, want %s", id, want)
+		}
+	}
+	if queued, _, _, _ := p.Stats(); queued != 0 {
+		t.Fatalf("expected no queued jobs, got %d", queued)
+	}
+}
+
+type splitCaller struct {
+	Caller
+	batches []*contract.Batch
+}
+
+func (c splitCaller) Split(addrs []string, j *Job) *contract.SplitResult {
+	return &contract.SplitResult{Batches: c.batches}
+}
+
+func TestSplitSubmitsBatches(t *testing.T) {
+	js := &fakeJobStore{}
+	s := &Scheduler{js: js, logger: log.Get("schedule")}
+	s.pool = newTestPool(1, 10, 0, PoolPolicyDrop, func(job *Job, retry bool) {})
+
+	parent := &Job{oid: primitive.NewObjectID(), fire: time.Now(), Task: "test", parallel: true}
+	parent.Id = parent.oid.Hex()
+	c := splitCaller{batches: []*contract.Batch{{Id: "1"}, {Id: "2"}}}
+	if !s.split(parent, c, nil) {
+		t.Fatal("job should be split")
+	}
+
+	// workers are not started, so children are left in queue instead of being dispatched directly
+	if len(js.created) != 2 || len(s.pool.queue) != 2 {
+		t.Fatalf("expected 2 children to be submitted, got %d saved, %d queued", len(js.created), len(s.pool.queue))
+	}
+	for i := 0; i < 2; i++ {
+		if pt := <-s.pool.queue; pt.job.parent != parent.Id || !pt.retry {
+			t.Fatalf("unexpected child job: %+v", pt.job)
+		}
+	}
+}
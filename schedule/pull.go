@@ -0,0 +1,140 @@
+package schedule
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/auxo/ext/times"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PullCaller queues jobs for tasks whose runner is `pull://{group}`, runners of the group pull jobs by long polling
+// and ack them after execution. A job which is not acked before visibility timeout is offered again, so it is
+// delivered at least once even if the runner crashed.
+type PullCaller struct {
+	s          *Scheduler
+	ps         store.PullStore
+	visibility time.Duration
+}
+
+func NewPullCaller(s *Scheduler, ps store.PullStore) *PullCaller {
+	visibility := config.GetDuration("skynet.pull.visibility")
+	if visibility <= 0 {
+		visibility = time.Minute
+	}
+	return &PullCaller{s: s, ps: ps, visibility: visibility}
+}
+
+func (c *PullCaller) Call(addrs []string, j *Job) *CallResult {
+	now := time.Now()
+	p := &store.Pull{
+		Id:         j.oid,
+		Group:      strings.TrimPrefix(addrs[0], "pull://"),
+		Task:       j.Task,
+		Handler:    j.Handler,
+		Args:       j.Args,
+		Mode:       j.Mode,
+		Fire:       store.Time(j.fire),
+		Attempt:    j.Attempt,
+		Visible:    store.Time(now),
+		CreateTime: store.Time(now),
+	}
+	if err := c.ps.Offer(p); err != nil {
+		return &CallResult{Code: contract.CodeFailed, Info: "failed to queue job: " + err.Error()}
+	}
+	return &CallResult{Address: addrs[0]}
+}
+
+// Cancel withdraws job if it is not pulled yet, a pulled job can't be cancelled because runner is unreachable.
+func (c *PullCaller) Cancel(addrs []string, id string) *CallResult {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &CallResult{Code: contract.CodeFailed, Info: err.Error()}
+	}
+
+	ok, err := c.ps.Withdraw(oid)
+	if err != nil {
+		return &CallResult{Code: contract.CodeFailed, Info: err.Error()}
+	} else if !ok {
+		return &CallResult{Code: contract.CodeNotSupported, Info: "job was already pulled by runner"}
+	}
+
+	// job may be already marked as timed out by sweeper
+	if j, err := c.s.js.Find(id); err == nil && j.Execute.Status == store.JobStatusUnknown {
+		now := time.Now()
+		if err = c.s.Notify(id, j.Attempt, contract.CodeCancelled, "job was cancelled before it was pulled", now, now); err != nil {
+			return &CallResult{Code: contract.CodeFailed, Info: err.Error()}
+		}
+	}
+	return &CallResult{}
+}
+
+func (c *PullCaller) Split(addrs []string, j *Job) *contract.SplitResult {
+	return &contract.SplitResult{Code: contract.CodeNotSupported, Info: "not supported"}
+}
+
+// Pull takes a job of group for runner, handlers limit jobs to those the runner can handle. It returns nil if there
+// is no job to run now.
+func (s *Scheduler) Pull(group string, handlers []string, runner string) (*contract.PulledJob, error) {
+	for {
+		p, err := s.puller.ps.Receive(group, handlers, runner, s.puller.visibility)
+		if err != nil || p == nil {
+			return nil, err
+		}
+
+		j, err := s.js.Find(p.Id.Hex())
+		if err == nil && j.Execute.Status == store.JobStatusUnknown && j.Attempt == p.Attempt {
+			return &contract.PulledJob{
+				Job: contract.Job{
+					Id:      p.Id.Hex(),
+					Task:    p.Task,
+					Handler: p.Handler,
+					Args:    p.Args,
+					Mode:    p.Mode,
+					Fire:    times.ToUnixMilli(time.Time(p.Fire)),
+					Attempt: p.Attempt,
+				},
+				Receipt: p.Receipt,
+			}, nil
+		} else if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		// job was finished, deleted or reattempted, drop the stale one
+		if _, err = s.puller.ps.Ack(p.Id, p.Receipt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Ack removes a pulled job from queue after runner finished it.
+func (s *Scheduler) Ack(id, receipt string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.puller.ps.Ack(oid, receipt)
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.Format("job '%s' was redelivered or acked already", id)
+	}
+	return nil
+}
+
+// extend keeps a pulled job invisible to other runners while runner is still executing it.
+func (s *Scheduler) extend(id, runner string) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil || runner == "" {
+		return
+	}
+	if err = s.puller.ps.Extend(oid, runner, s.puller.visibility); err != nil {
+		s.logger.Warnf("failed to extend visibility of job '%s': %s", id, err)
+	}
+}
@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/ext/times"
+	"github.com/cuigh/skynet/store"
+)
+
+// maxRetryInterval limits interval of exponential backoff.
+const maxRetryInterval = time.Hour
+
+// retryInterval returns interval before next attempt, ok is false if task should not be retried.
+func retryInterval(t *store.Task, on int32, attempt int32) (d time.Duration, ok bool) {
+	policy := t.Retry
+	if policy.On&on == 0 || attempt >= policy.Attempts {
+		return 0, false
+	}
+
+	d = time.Duration(policy.Interval) * time.Second
+	if policy.Backoff == store.BackoffExponential {
+		for i := int32(1); i < attempt && d < maxRetryInterval; i++ {
+			d *= 2
+		}
+		if d > maxRetryInterval {
+			d = maxRetryInterval
+		}
+	}
+	return d, true
+}
+
+// fail retries job if retry policy of task allows, otherwise raises an alert.
+func (s *Scheduler) fail(id string, on int32, info string) {
+	j, err := s.js.Find(id)
+	if err != nil {
+		s.logger.Errorf("failed to find job '%s': %s", id, err)
+		return
+	}
+
+	t, err := s.tf.Find(j.Task)
+	if err != nil {
+		s.logger.Errorf("failed to find task '%s': %s", j.Task, err)
+		s.alerter.Alert(id, info)
+		return
+	}
+
+	attempt := j.Attempt
+	if attempt == 0 {
+		attempt = 1
+	}
+	d, ok := retryInterval(t, on, attempt)
+	if !ok {
+		// failures of batch jobs are alerted by parent job
+		if j.Parent == "" {
+			s.alerter.Alert(id, info)
+		}
+		s.finish(j, false)
+		return
+	}
+
+	if ok, err = s.js.Reattempt(j, time.Now().Add(d)); err != nil {
+		s.logger.Errorf("failed to reattempt job '%s': %s", id, err)
+		s.alerter.Alert(id, info)
+		return
+	} else if !ok {
+		s.logger.Debugf("job '%s' was already reattempted by another node", id)
+		return
+	}
+
+	s.logger.Infof("job '%s' failed(attempt: %d): %s, retry after %s", id, attempt, info, d)
+	s.retryLater(newRetryJob(j, t), d)
+}
+
+// finish propagates final result of job to its parent job or workflow run.
+func (s *Scheduler) finish(j *store.Job, success bool) {
+	if j.Parent != "" {
+		s.settle(j.Parent, success)
+		return
+	}
+
+	s.vacate(j)
+	s.resume(j)
+	if j.Run != "" {
+		s.finishNode(j.Run, j.Node, success)
+	}
+}
+
+func newRetryJob(j *store.Job, t *store.Task) *Job {
+	return &Job{
+		oid:      j.Id,
+		fire:     time.Time(j.FireTime),
+		runner:   t.Runner,
+		timeout:  time.Duration(t.Timeout.Duration) * time.Second,
+		dispatch: time.Duration(t.Timeout.Dispatch) * time.Second,
+		parallel: t.Parallel && j.Parent == "" && j.Batches == nil, // only jobs failed to split are retried as a whole
+		parent:   j.Parent,
+		batch:    j.Batch,
+		run:      j.Run,
+		node:     j.Node,
+		Id:       j.Id.Hex(),
+		Task:     j.Task,
+		Handler:  j.Handler,
+		Mode:     j.Mode,
+		Fire:     times.ToUnixMilli(time.Time(j.FireTime)),
+		Args:     j.Args,
+		Attempt:  j.Attempt,
+	}
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/store"
+)
+
+func TestRetryInterval(t *testing.T) {
+	cases := []struct {
+		name     string
+		attempts int32
+		on       int32
+		backoff  int32
+		interval int32
+		failed   int32 // failure of job
+		attempt  int32 // attempt which failed
+		want     time.Duration
+		ok       bool
+	}{
+		{"no retry", 0, store.RetryOnExecute, store.BackoffFixed, 10, store.RetryOnExecute, 1, 0, false},
+		{"single attempt", 1, store.RetryOnExecute, store.BackoffFixed, 10, store.RetryOnExecute, 1, 0, false},
+		{"other failure", 3, store.RetryOnDispatch, store.BackoffFixed, 10, store.RetryOnExecute, 1, 0, false},
+		{"both failures", 3, store.RetryOnDispatch | store.RetryOnExecute, store.BackoffFixed, 10, store.RetryOnExecute, 1, 10 * time.Second, true},
+		{"fixed", 3, store.RetryOnExecute, store.BackoffFixed, 10, store.RetryOnExecute, 2, 10 * time.Second, true},
+		{"attempts exhausted", 3, store.RetryOnExecute, store.BackoffFixed, 10, store.RetryOnExecute, 3, 0, false},
+		{"exponential first", 5, store.RetryOnExecute, store.BackoffExponential, 10, store.RetryOnExecute, 1, 10 * time.Second, true},
+		{"exponential third", 5, store.RetryOnExecute, store.BackoffExponential, 10, store.RetryOnExecute, 3, 40 * time.Second, true},
+		{"exponential capped", 100, store.RetryOnExecute, store.BackoffExponential, 60, store.RetryOnExecute, 50, maxRetryInterval, true},
+		{"immediate", 3, store.RetryOnDispatch, store.BackoffExponential, 0, store.RetryOnDispatch, 2, 0, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			task := &store.Task{Name: "test"}
+			task.Retry.Attempts = c.attempts
+			task.Retry.On = c.on
+			task.Retry.Backoff = c.backoff
+			task.Retry.Interval = c.interval
+
+			d, ok := retryInterval(task, c.failed, c.attempt)
+			if d != c.want || ok != c.ok {
+				t.Fatalf("got (%s, %v), want (%s, %v)", d, ok, c.want, c.ok)
+			}
+		})
+	}
+}
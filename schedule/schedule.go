@@ -1,7 +1,10 @@
 package schedule
 
 import (
+	"context"
 	"github.com/cuigh/auxo/app/ioc"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cuigh/auxo/app"
@@ -10,6 +13,7 @@ import (
 	"github.com/cuigh/auxo/errors"
 	"github.com/cuigh/auxo/ext/times"
 	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/contract"
 	"github.com/cuigh/skynet/lock"
 	"github.com/cuigh/skynet/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,6 +22,7 @@ import (
 const (
 	ModeAuto int32 = iota
 	ModeManual
+	ModeWorkflow
 )
 
 // 调用器(Caller)：任务远程调用实现
@@ -27,71 +32,134 @@ const (
 // 作业(Job)：一次具体的执行任务
 
 type Job struct {
-	oid     primitive.ObjectID
-	fire    time.Time
-	runner  string
-	Id      string       `json:"id"`
-	Task    string       `json:"task"`
-	Handler string       `json:"handler"`
-	Args    data.Options `json:"args"`
-	Mode    int32        `json:"mode"` // 0-auto, 1-manual
-	Fire    int64        `json:"fire"`
+	oid         primitive.ObjectID
+	fire        time.Time
+	runner      string
+	timeout     time.Duration
+	dispatch    time.Duration // timeout of requests to runner, 0 means default of caller
+	parallel    bool          // split job into batches
+	parent      string        // id of parent job for batch job
+	batch       string        // id of batch for batch job
+	run         string        // id of workflow run for workflow node job
+	node        string        // id of workflow node for workflow node job
+	concurrency int32         // max running jobs of task, 0 means unlimited
+	overlap     int32         // policy when concurrency limit is reached
+	delayed     bool          // dispatched when fire time is due
+	jitter      time.Duration // delay of dispatching after fire time, it is not counted as dispatch lag
+	Id          string        `json:"id"`
+	Task        string        `json:"task"`
+	Handler     string        `json:"handler"`
+	Args        data.Options  `json:"args"`
+	Mode        int32         `json:"mode"` // 0-auto, 1-manual, 2-workflow
+	Fire        int64         `json:"fire"`
+	Attempt     int32         `json:"attempt,omitempty"`
 }
 
 func NewJob(t *store.Task, args data.Options, mode int32, fire time.Time) *Job {
 	id := primitive.NewObjectID()
 	return &Job{
-		oid:     id,
-		fire:    fire,
-		runner:  t.Runner,
-		Id:      id.Hex(),
-		Task:    t.Name,
-		Handler: t.Handler,
-		Mode:    mode,
-		Fire:    times.ToUnixMilli(fire),
-		Args:    mergeArgs(t.Args, args),
+		oid:         id,
+		fire:        fire,
+		runner:      t.Runner,
+		timeout:     time.Duration(t.Timeout.Duration) * time.Second,
+		dispatch:    time.Duration(t.Timeout.Dispatch) * time.Second,
+		parallel:    t.Parallel,
+		concurrency: t.Concurrency.Max,
+		overlap:     t.Concurrency.Overlap,
+		Id:          id.Hex(),
+		Task:        t.Name,
+		Handler:     t.Handler,
+		Mode:        mode,
+		Fire:        times.ToUnixMilli(fire),
+		Args:        mergeArgs(t.Args, args),
+		Attempt:     1,
 	}
 }
 
 // Scheduler dispatch task to executors to run by plan.
 type Scheduler struct {
 	node     string
+	cluster  *Cluster
+	pool     *Pool
+	tick     int64 // unix milliseconds of last heap check, accessed atomically
+	fire     int64 // unix milliseconds of last fire dispatched by heap, accessed atomically
+	size     int64 // count of tasks in heap, accessed atomically
+	stopping int32 // 1 if scheduler is stopping, accessed atomically
+	running  int64 // count of in-flight dispatches and job writes, accessed atomically
 	tf       *TaskFetcher
 	th       *TaskHeap
 	lock     lock.Lock
 	resolver Resolver
 	logger   log.Logger
 	js       store.JobStore
+	fs       store.FireStore
+	ss       store.SlotStore
+	ws       store.WorkflowStore
+	rs       store.RunStore
 	updater  chan *TaskHeap
+	changes  chan *TaskChange
+	ended    chan *store.Job // finished auto jobs, used to schedule fixed-delay tasks
+	delayed  chan struct{}   // notified when a delayed job is submitted
 	alerter  *Alerter
 	closer   chan struct{}
 	callers  map[string]Caller
+	puller   *PullCaller
+	locker   sync.Mutex
+	retries  map[primitive.ObjectID]*time.Timer // pending retries, keyed by job id
 }
 
-func NewScheduler(lock lock.Lock, resolver Resolver, ts store.TaskStore, js store.JobStore, alerter *Alerter) *Scheduler {
+func NewScheduler(lock lock.Lock, resolver Resolver, ts store.TaskStore, js store.JobStore,
+	ws store.WorkflowStore, rs store.RunStore, cs store.CalendarStore, ns store.NodeStore, ps store.PullStore,
+	ss store.SlotStore, fs store.FireStore, alerter *Alerter) *Scheduler {
 	logger := log.Get("schedule")
 	node := config.GetString("skynet.node")
 	if node == "" {
 		node = primitive.NewObjectID().Hex()[:8]
 	}
-	return &Scheduler{
-		node: node,
+	cluster := NewCluster(node, ns, logger)
+	hc, gc := NewHTTPCaller(), NewGRPCCaller()
+	s := &Scheduler{
+		node:    node,
+		cluster: cluster,
 		callers: map[string]Caller{
-			"http": HTTPCaller{},
+			"http":  hc,
+			"https": hc,
+			"grpc":  gc,
+			"grpcs": gc,
+			"exec":  ExecCaller{hc},
+			"execs": ExecCaller{hc},
 		},
 		lock:     lock,
 		resolver: resolver,
-		tf:       NewTaskFetcher(ts, logger),
+		tf:       NewTaskFetcher(ts, js, fs, cs, cluster, logger),
 		js:       js,
+		fs:       fs,
+		ss:       ss,
+		ws:       ws,
+		rs:       rs,
 		alerter:  alerter,
 		updater:  make(chan *TaskHeap, 1),
+		changes:  make(chan *TaskChange, 100),
+		ended:    make(chan *store.Job, 100),
+		delayed:  make(chan struct{}, 1),
+		retries:  make(map[primitive.ObjectID]*time.Timer),
 		closer:   make(chan struct{}),
 		logger:   logger,
 	}
+	s.callers["workflow"] = WorkflowCaller{s: s}
+	s.callers["local"] = LocalCaller{s: s}
+	s.puller = NewPullCaller(s, ps)
+	s.callers["pull"] = s.puller
+	s.pool = NewPool(s.call, logger)
+	return s
 }
 
 func (s *Scheduler) Start() {
-	s.tf.Start(s.updater)
+	s.cluster.Start(s.closer, s.report)
+	s.pool.Start(s.closer)
+	s.tf.Start(s.updater, s.changes)
+	go s.sweep()
+	go s.delay()
 
 	var t Timer
 	defer t.Stop()
@@ -101,29 +169,38 @@ func (s *Scheduler) Start() {
 		select {
 		case <-t.C:
 			continue
-		case s.th = <-s.updater:
+		case th := <-s.updater:
+			if s.th != nil {
+				// jobs dispatched by current heap may not be saved yet
+				th.inherit(s.th)
+			}
+			s.th = th
 			s.logger.Info("update tasks")
 			continue
+		case c := <-s.changes:
+			if s.th != nil {
+				s.th.replace(c.Name, c.Item)
+			}
+			continue
+		case j := <-s.ended:
+			if s.th != nil {
+				end := endTime(j)
+				if end.IsZero() {
+					end = time.Now()
+				}
+				s.th.resume(j.Task, end)
+			}
+			continue
 		case <-s.closer:
 			return
 		}
 	}
 }
 
-func (s *Scheduler) Stop() {
-	close(s.closer)
-	s.tf.Stop()
-}
-
 // Execute dispatches task immediately.
 func (s *Scheduler) Execute(name string, args data.Options) error {
-	task, err := s.tf.Find(name)
-	if err != nil {
-		return err
-	}
-	job := NewJob(task, args, ModeManual, time.Now())
-	s.call(job, false)
-	return nil
+	_, err := s.Submit(name, args, time.Now())
+	return err
 }
 
 func (s *Scheduler) try() time.Duration {
@@ -133,20 +210,29 @@ func (s *Scheduler) try() time.Duration {
 	}
 
 	now := time.Now()
+	atomic.StoreInt64(&s.tick, times.ToUnixMilli(now))
+	atomic.StoreInt64(&s.size, int64(s.th.Count()))
 	for {
 		item := s.th.Peek()
 		if item == nil {
 			return time.Minute
 		}
 
-		if d := item.fire.Sub(now); d > 0 {
+		due := item.due()
+		if d := due.Sub(now); d > 0 {
 			return d
 		}
 
 		job := NewJob(item.task, nil, ModeAuto, item.fire)
-		go s.call(job, false)
+		job.jitter = due.Sub(item.fire)
+		s.pool.Submit(job, false)
+		atomic.StoreInt64(&s.fire, job.Fire)
+		if item.task.Trigger.Type == store.TriggerOnce {
+			s.spawn(func() { s.disable(item.task.Name) })
+		}
 
 		// update next fire time of task
+		item.dispatched()
 		item.next(now)
 		s.th.Update(0)
 	}
@@ -158,71 +244,223 @@ func (s *Scheduler) Retry(id string) error {
 		return err
 	}
 
+	if j.Batches != nil {
+		return errors.Format("job '%s' was split into batches, retry batch jobs instead", id)
+	}
+	if j.Delayed {
+		return errors.Format("job '%s' is waiting for fire time", id)
+	}
+
 	t, err := s.tf.Find(j.Task)
 	if err != nil {
 		return err
 	}
 
-	job := &Job{
-		//oid:
-		fire:    time.Time(j.FireTime),
-		runner:  t.Runner,
-		Id:      j.Id.Hex(),
-		Task:    j.Task,
-		Handler: j.Handler,
-		Mode:    j.Mode,
-		Fire:    times.ToUnixMilli(time.Time(j.FireTime)),
-		Args:    j.Args,
+	ok, err := s.js.Reattempt(j, time.Now())
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.Format("job '%s' is being retried", id)
 	}
-	s.call(job, true)
+
+	s.call(newRetryJob(j, t), true)
+	return nil
+}
+
+// Notify handles execution result reported by runner, attempt is 0 if runner doesn't report it.
+func (s *Scheduler) Notify(id string, attempt, code int32, info string, start, end time.Time) error {
+	status := store.JobStatusFailed
+	switch code {
+	case contract.CodeSuccess:
+		status = store.JobStatusSuccess
+	case contract.CodeCancelled:
+		status = store.JobStatusCancelled
+	}
+
+	j, err := s.js.ModifyExecute(id, attempt, status, info, start, end)
+	if err != nil {
+		return err
+	} else if j == nil {
+		// duplicate report, or job was settled by sweeper/cancellation already
+		s.logger.Warnf("ignored result of job '%s'(attempt: %d), it was settled already", id, attempt)
+		return nil
+	}
+
+	if status == store.JobStatusFailed {
+		s.spawn(func() { s.fail(id, store.RetryOnExecute, info) })
+	} else {
+		s.spawn(func() { s.finish(j, status == store.JobStatusSuccess) })
+	}
+	return nil
+}
+
+// Cancel asks the runner which accepted the job to cancel it, a delayed job is cancelled before it is dispatched.
+func (s *Scheduler) Cancel(id string) error {
+	j, err := s.js.Find(id)
+	if err != nil {
+		return err
+	}
+	if j.Delayed {
+		if ok, err := s.js.CancelDelayed(j.Id); err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+		// it was claimed just now, so try to cancel it as a running job
+		if j, err = s.js.Find(id); err != nil {
+			return err
+		}
+	}
+	if j.Dispatch.Status != store.JobStatusSuccess || j.Execute.Status != store.JobStatusUnknown {
+		return errors.Format("job '%s' is not running", id)
+	}
+
+	t, err := s.tf.Find(j.Task)
+	if err != nil {
+		return err
+	}
+
+	if j.Batches != nil {
+		return s.cancelBatches(j, t.Runner)
+	}
+
+	r := s.cancel(j, t.Runner)
+	if !r.Success() {
+		return errors.Coded(r.Code, r.Info)
+	}
+	return nil
+}
+
+// Heartbeat records that job is still running on runner.
+func (s *Scheduler) Heartbeat(id, runner string) error {
+	if err := s.js.Heartbeat(id, runner); err != nil {
+		return err
+	}
+	s.extend(id, runner)
 	return nil
 }
 
 func (s *Scheduler) call(job *Job, retry bool) {
-	if job.Mode == ModeAuto && !s.lock.Lock(job.Task, job.fire) {
+	defer s.track()()
+
+	if !retry && job.Mode == ModeAuto && !s.lock.Lock(job.Task, job.fire) {
 		s.logger.Debugf("task {name: %s, fire: %s} was already dispatched by another node", job.Task, job.Fire)
 		return
 	}
+	if s.isStopping() {
+		s.handover(job, retry)
+		return
+	}
 
 	// save job info
 	if !retry {
-		err := s.js.Create(&store.Job{
-			Id:        job.oid,
-			Task:      job.Task,
-			Handler:   job.Handler,
-			Scheduler: s.node,
-			Args:      job.Args,
-			Mode:      job.Mode,
-			FireTime:  store.Time(job.fire),
-		})
-		if err != nil {
+		if err := s.save(job); err != nil {
 			s.logger.Errorf("failed to save job to db: %s", err)
 			// TODO: terminate dispatch or just ignore err?
 			return
 		}
+		if !s.admit(job) {
+			return
+		}
 	}
 
 	// dispatch
 	schema, addrs, err := s.resolver.Resolve(job.runner)
 	if err != nil {
 		s.logger.Errorf("failed to resolve runner: %s", err)
+		s.dispatched(job, &CallResult{Code: contract.CodeFailed, Info: "failed to resolve runner: " + err.Error()})
 		return
 	}
 	caller := s.callers[schema]
 	if caller == nil {
 		s.logger.Errorf("caller not found: %s", schema)
+		s.dispatched(job, &CallResult{Code: contract.CodeNotSupported, Info: "caller not found: " + schema})
+		return
+	}
+	if job.parallel && s.split(job, caller, addrs) {
+		return
+	}
+	s.dispatch(job, caller, addrs)
+}
+
+// report fills runtime status of scheduler into heartbeat of node.
+func (s *Scheduler) report(n *store.Node) {
+	n.HeapSize = int(atomic.LoadInt64(&s.size))
+	if tick := atomic.LoadInt64(&s.tick); tick > 0 {
+		t := store.Time(times.FromUnixMilli(tick))
+		n.LastTick = &t
+	}
+	if fire := atomic.LoadInt64(&s.fire); fire > 0 {
+		t := store.Time(times.FromUnixMilli(fire))
+		n.LastFire = &t
+	}
+	queued, busy, dropped, lag := s.pool.Stats()
+	n.Queued, n.Busy, n.Dropped, n.Lag = queued, busy, dropped, lag.Milliseconds()
+}
+
+// disable disables one-shot task after it was fired.
+func (s *Scheduler) disable(name string) {
+	if err := s.tf.ts.Disable(name); err != nil {
+		s.logger.Errorf("failed to disable task '%s': %s", name, err)
+	}
+}
+
+// resume schedules next fire of fixed-delay task after its auto job is finished.
+func (s *Scheduler) resume(j *store.Job) {
+	if j.Mode != ModeAuto || j.Parent != "" {
 		return
 	}
-	result := caller.Call(addrs, job)
+	select {
+	case s.ended <- j:
+	case <-s.closer:
+	}
+}
+
+func (s *Scheduler) save(job *Job) error {
+	due := store.Time(time.Now())
+	err := s.js.Create(&store.Job{
+		Id:        job.oid,
+		Task:      job.Task,
+		Handler:   job.Handler,
+		Scheduler: s.node,
+		Args:      job.Args,
+		Mode:      job.Mode,
+		FireTime:  store.Time(job.fire),
+		Attempt:   job.Attempt,
+		Parent:    job.parent,
+		Batch:     job.batch,
+		Run:       job.run,
+		Node:      job.node,
+		Delayed:   job.delayed,
+		Dispatch:  store.JobDispatch{Due: &due},
+	})
+	if err == nil && job.Mode == ModeAuto && job.parent == "" {
+		// misfires are found by it after restarting
+		if e := s.fs.Record(job.Task, job.fire); e != nil {
+			s.logger.Errorf("failed to record fire time of task '%s': %s", job.Task, e)
+		}
+	}
+	return err
+}
+
+func (s *Scheduler) dispatch(job *Job, caller Caller, addrs []string) {
+	s.dispatched(job, caller.Call(addrs, job))
+}
 
+// dispatched records result of dispatching, job is retried or finished as failed if dispatching failed.
+func (s *Scheduler) dispatched(job *Job, result *CallResult) {
 	// update control info
-	err = s.js.ModifyDispatch(job.Id, result.Success(), result.Info)
+	var deadline time.Time
+	if result.Success() && job.timeout > 0 {
+		deadline = time.Now().Add(job.timeout)
+	}
+	err := s.js.ModifyDispatch(job.Id, result.Success(), result.Info, result.Address, deadline)
 	if err != nil {
 		s.logger.Errorf("failed to update job control info: %s", err)
 	}
 
 	if !result.Success() {
-		go s.alerter.Alert(job.Id, result.Info)
+		s.spawn(func() { s.fail(job.Id, store.RetryOnDispatch, result.Info) })
 	}
 }
 
@@ -251,18 +489,45 @@ func mergeArgs(args1, args2 data.Options) data.Options {
 	return args
 }
 
+// TaskChange replaces item of a task in TaskHeap, Item is nil if task was deleted, disabled or paused.
+type TaskChange struct {
+	Name string
+	Item *TaskItem
+}
+
+// TaskFetcher loads tasks into TaskHeap. Changes of tasks are applied incrementally by change stream,
+// polling is kept as a fallback which rebuilds the whole heap if state of tasks or calendars changed.
 type TaskFetcher struct {
-	modify time.Time // last modify time of tasks
-	th     *TaskHeap
-	ts     store.TaskStore
-	timer  *time.Timer
-	logger log.Logger
+	locker    sync.Mutex
+	loaded    bool
+	modify    time.Time // last modify time of tasks
+	count     int64     // count of enabled tasks
+	calModify time.Time // last modify time of calendars
+	calCount  int64     // count of calendars
+	version   int       // version of cluster membership which heap was loaded with
+	ts        store.TaskStore
+	js        store.JobStore
+	fs        store.FireStore
+	cs        store.CalendarStore
+	cluster   *Cluster
+	heaps     chan<- *TaskHeap
+	changes   chan<- *TaskChange
+	timer     *time.Timer
+	cancel    context.CancelFunc
+	done      chan struct{}
+	logger    log.Logger
 }
 
-func NewTaskFetcher(ts store.TaskStore, logger log.Logger) *TaskFetcher {
+func NewTaskFetcher(ts store.TaskStore, js store.JobStore, fs store.FireStore, cs store.CalendarStore, cluster *Cluster,
+	logger log.Logger) *TaskFetcher {
 	return &TaskFetcher{
-		ts:     ts,
-		logger: logger,
+		ts:      ts,
+		js:      js,
+		fs:      fs,
+		cs:      cs,
+		cluster: cluster,
+		done:    make(chan struct{}),
+		logger:  logger,
 	}
 }
 
@@ -270,44 +535,283 @@ func (f *TaskFetcher) Find(name string) (*store.Task, error) {
 	return f.ts.Find(name)
 }
 
-func (f *TaskFetcher) Start(c chan<- *TaskHeap) {
-	if f.refresh() {
-		c <- f.th
-	}
+func (f *TaskFetcher) Start(heaps chan<- *TaskHeap, changes chan<- *TaskChange) {
+	f.heaps, f.changes = heaps, changes
+	f.refresh(false)
 
 	f.timer = time.AfterFunc(time.Minute, func() {
-		if f.refresh() {
-			c <- f.th
-		}
+		f.refresh(false)
 		f.timer.Reset(time.Minute)
 	})
+
+	var ctx context.Context
+	ctx, f.cancel = context.WithCancel(context.Background())
+	go f.watch(ctx)
+	go f.rebalance()
 }
 
 func (f *TaskFetcher) Stop() {
+	close(f.done)
+	if f.cancel != nil {
+		f.cancel()
+	}
 	if f.timer != nil {
 		f.timer.Stop()
 	}
 }
 
-func (f *TaskFetcher) refresh() (updated bool) {
+// watch keeps a change stream of tasks open, the heap is rebuilt after the stream is reopened
+// because changes may be missed while it was closed.
+func (f *TaskFetcher) watch(ctx context.Context) {
+	reopen := false
+	for {
+		err := f.ts.Watch(ctx, func() {
+			f.logger.Info("start watching changes of tasks")
+			f.refresh(reopen)
+			reopen = true
+		}, f.reload)
+		if ctx.Err() != nil {
+			return
+		}
+
+		f.logger.Warn("failed to watch changes of tasks, fall back to polling: ", err)
+		select {
+		case <-time.After(5 * time.Minute):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// rebalance rebuilds the heap with tasks owned by current node whenever cluster membership changed.
+func (f *TaskFetcher) rebalance() {
+	for {
+		select {
+		case <-f.cluster.changed:
+			f.refresh(false)
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// reload rebuilds item of a changed task and sends it to scheduler, t is nil if task was deleted.
+func (f *TaskFetcher) reload(name string, t *store.Task) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if !f.loaded {
+		// whole heap will be loaded by polling
+		return
+	}
+
+	c := &TaskChange{Name: name}
+	if t != nil && t.Enabled && t.Pause == nil && f.cluster.Owns(name) {
+		item, err := f.build(t)
+		if err != nil {
+			f.logger.Errorf("failed to reload task '%s': %s", name, err)
+			// let polling rebuild the whole heap
+			f.loaded = false
+			return
+		}
+		c.Item = item
+	}
+
+	// keep state up to date, so polling doesn't rebuild the heap for changes applied here
+	if modify, count, err := f.ts.GetState(); err == nil {
+		f.modify, f.count = modify, count
+	} else {
+		f.modify = time.Time{}
+	}
+
+	select {
+	case f.changes <- c:
+		f.logger.Debugf("task '%s' reloaded", name)
+	case <-f.done:
+	}
+}
+
+// build creates TaskItem for a single task.
+func (f *TaskFetcher) build(t *store.Task) (*TaskItem, error) {
+	tasks := []*store.Task{t}
+	lasts, err := f.lastFireTimes(tasks)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := f.lastEndTimes(tasks)
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := f.calendars(tasks)
+	if err != nil {
+		return nil, err
+	}
+	return newHeapItem(t, calendars, lasts, ends, time.Now())
+}
+
+// refresh rebuilds the whole heap if state of tasks, calendars or cluster membership changed since last loading,
+// or force is true.
+func (f *TaskFetcher) refresh(force bool) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	if n, err := f.ts.ResumeExpired(time.Now()); err != nil {
+		f.logger.Error("failed to resume expired tasks: ", err)
+	} else if n > 0 {
+		f.logger.Infof("%d paused tasks were resumed", n)
+	}
+
 	modify, count, err := f.ts.GetState()
 	if err != nil {
 		f.logger.Error("failed to fetch task state: ", err)
-		return false
+		return
+	}
+	calModify, calCount, err := f.cs.GetState()
+	if err != nil {
+		f.logger.Error("failed to fetch calendar state: ", err)
+		return
+	}
+	// fetch version before filtering tasks, so heap is rebuilt again if membership changed meanwhile
+	version := f.cluster.Version()
+	if !force && f.loaded && f.version == version && f.count == count && f.modify.Equal(modify) &&
+		f.calCount == calCount && f.calModify.Equal(calModify) {
+		return
 	}
-	if f.th != nil && f.th.Count() == int(count) && f.modify.Equal(modify) {
-		return false
+	if f.loaded && f.version != version {
+		f.logger.Info("rebalance tasks")
 	}
 
-	var tasks []*store.Task
-	tasks, err = f.ts.FetchAll(true)
+	all, err := f.ts.FetchAll(true)
 	if err != nil {
 		f.logger.Error("failed to fetch tasks: ", err)
-		return false
+		return
+	}
+
+	// paused tasks are not triggered automatically, and tasks owned by other nodes are scheduled by them
+	var tasks []*store.Task
+	for _, t := range all {
+		if t.Pause == nil && f.cluster.Owns(t.Name) {
+			tasks = append(tasks, t)
+		}
 	}
 
-	f.th, f.modify = NewTaskHeap(tasks), modify
-	return true
+	lasts, err := f.lastFireTimes(tasks)
+	if err != nil {
+		f.logger.Error("failed to fetch last fire times: ", err)
+		return
+	}
+
+	ends, err := f.lastEndTimes(tasks)
+	if err != nil {
+		f.logger.Error("failed to fetch last end times: ", err)
+		return
+	}
+
+	calendars, err := f.calendars(tasks)
+	if err != nil {
+		f.logger.Error("failed to fetch calendars: ", err)
+		return
+	}
+
+	th := NewTaskHeap(tasks, calendars, lasts, ends)
+	select {
+	case f.heaps <- th:
+		f.loaded, f.modify, f.count = true, modify, count
+		f.calModify, f.calCount = calModify, calCount
+		f.version = version
+	case <-f.done:
+	}
+}
+
+// calendars returns calendars referenced by tasks.
+func (f *TaskFetcher) calendars(tasks []*store.Task) (map[string]*store.Calendar, error) {
+	var names []string
+	set := make(map[string]struct{})
+	for _, t := range tasks {
+		for _, name := range t.Calendars {
+			if _, ok := set[name]; !ok {
+				set[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+	return f.cs.FetchMany(names)
+}
+
+// lastFireTimes returns fire time of last dispatched job for tasks which need misfire handling. Fire times are
+// recorded separately because jobs expire, those of tasks without record are found from jobs.
+func (f *TaskFetcher) lastFireTimes(tasks []*store.Task) (map[string]time.Time, error) {
+	var names []string
+	for _, t := range tasks {
+		if t.Misfire.Policy != store.MisfireSkip {
+			names = append(names, t.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	lasts, err := f.fs.GetLastFireTimes(names)
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks which haven't fired since fire times were recorded
+	var missing []string
+	for _, name := range names {
+		if _, ok := lasts[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		m, err := f.js.GetLastFireTimes(missing)
+		if err != nil {
+			return nil, err
+		}
+		for name, fire := range m {
+			lasts[name] = fire
+		}
+	}
+	return lasts, nil
+}
+
+// lastEndTimes returns end time of last job for fixed-delay tasks, it is zero if the job is not finished yet.
+func (f *TaskFetcher) lastEndTimes(tasks []*store.Task) (map[string]time.Time, error) {
+	var names []string
+	for _, t := range tasks {
+		if t.Trigger.Type == store.TriggerDelay {
+			names = append(names, t.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	jobs, err := f.js.GetLastJobs(names)
+	if err != nil {
+		return nil, err
+	}
+
+	ends := make(map[string]time.Time, len(jobs))
+	for name, j := range jobs {
+		ends[name] = endTime(j)
+	}
+	return ends, nil
+}
+
+// endTime returns end time of job, it is zero if job is not finished yet.
+func endTime(j *store.Job) time.Time {
+	if j.Execute.EndTime != nil {
+		return time.Time(*j.Execute.EndTime)
+	}
+	if j.Execute.Status != store.JobStatusUnknown || j.Dispatch.Status == store.JobStatusFailed {
+		// job was skipped or failed to dispatch
+		if j.Dispatch.Time != nil {
+			return time.Time(*j.Dispatch.Time)
+		}
+		return time.Time(j.FireTime)
+	}
+	return time.Time{}
 }
 
 type Timer struct {
@@ -0,0 +1,112 @@
+package schedule
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stop shuts down scheduler gracefully. It stops taking new fires, hands over jobs which are not dispatched yet
+// to other nodes, waits for in-flight dispatches and job writes until timeout, and unregisters current node.
+func (s *Scheduler) Stop(timeout time.Duration) {
+	if !atomic.CompareAndSwapInt32(&s.stopping, 0, 1) {
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	s.logger.Info("scheduler is stopping")
+	close(s.closer)
+	s.tf.Stop()
+	s.pool.Drain()
+	s.handoverRetries()
+
+	if n := s.wait(deadline); n > 0 {
+		s.logger.Warnf("scheduler stopped with %d dispatches unfinished", n)
+	}
+	s.cluster.Stop()
+	s.logger.Info("scheduler stopped")
+}
+
+func (s *Scheduler) isStopping() bool {
+	return atomic.LoadInt32(&s.stopping) == 1
+}
+
+// track counts an in-flight dispatch or job write, the returned func must be called after it is finished.
+func (s *Scheduler) track() func() {
+	atomic.AddInt64(&s.running, 1)
+	return func() { atomic.AddInt64(&s.running, -1) }
+}
+
+// spawn runs fn in a new goroutine which is waited by Stop.
+func (s *Scheduler) spawn(fn func()) {
+	done := s.track()
+	go func() {
+		defer done()
+		fn()
+	}()
+}
+
+// wait waits for tracked goroutines until deadline, it returns count of unfinished ones.
+func (s *Scheduler) wait(deadline time.Time) int64 {
+	for {
+		n := atomic.LoadInt64(&s.running)
+		if n == 0 || !time.Now().Before(deadline) {
+			return n
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// handover leaves a job which is not dispatched yet to other nodes when scheduler is stopping. Jobs are persisted
+// as delayed ones, which are dispatched by the delay loop of any alive node. Lock of an auto fire is kept, so the
+// fire is dispatched only once by the delayed job even if another node takes over the task.
+func (s *Scheduler) handover(job *Job, retry bool) {
+	var err error
+	if retry {
+		err = s.js.Delay(job.oid)
+	} else {
+		job.delayed = true
+		err = s.save(job)
+	}
+
+	if err != nil {
+		s.logger.Errorf("failed to hand over job '%s' of task '%s': %s", job.Id, job.Task, err)
+	} else {
+		s.logger.Infof("job '%s' of task '%s' is handed over to other nodes", job.Id, job.Task)
+	}
+}
+
+// retryLater dispatches job again after d, it is handed over to other nodes if scheduler stops before that.
+func (s *Scheduler) retryLater(job *Job, d time.Duration) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	if s.isStopping() {
+		s.handover(job, true)
+		return
+	}
+
+	s.retries[job.oid] = time.AfterFunc(d, func() {
+		s.locker.Lock()
+		delete(s.retries, job.oid)
+		s.locker.Unlock()
+
+		s.pool.Submit(job, true)
+	})
+}
+
+// handoverRetries cancels pending retries and hands them over to other nodes, they are retried immediately.
+func (s *Scheduler) handoverRetries() {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	for id, t := range s.retries {
+		if t.Stop() {
+			if err := s.js.Delay(id); err != nil {
+				s.logger.Errorf("failed to hand over retry of job '%s': %s", id.Hex(), err)
+			}
+		}
+	}
+	s.retries = make(map[primitive.ObjectID]*time.Timer)
+}
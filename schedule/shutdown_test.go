@@ -0,0 +1,91 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeJobStore struct {
+	store.JobStore
+	created []*store.Job
+	delayed []primitive.ObjectID
+	lasts   map[string]time.Time
+}
+
+func (s *fakeJobStore) Create(job *store.Job) error {
+	s.created = append(s.created, job)
+	return nil
+}
+
+func (s *fakeJobStore) Delay(id primitive.ObjectID) error {
+	s.delayed = append(s.delayed, id)
+	return nil
+}
+
+func (s *fakeJobStore) GetLastFireTimes(tasks []string) (map[string]time.Time, error) {
+	m := make(map[string]time.Time)
+	for _, t := range tasks {
+		if fire, ok := s.lasts[t]; ok {
+			m[t] = fire
+		}
+	}
+	return m, nil
+}
+
+func (s *fakeJobStore) SetBatches(id string, total int32) error {
+	return nil
+}
+
+func (s *fakeJobStore) ModifyDispatch(id string, success bool, error, address string, deadline time.Time) error {
+	return nil
+}
+
+type fakeLock struct {
+	unlocked int
+}
+
+func (l *fakeLock) Lock(name string, fire time.Time) bool {
+	return true
+}
+
+func (l *fakeLock) Unlock(name string, fire time.Time) bool {
+	l.unlocked++
+	return true
+}
+
+func TestHandover(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    int32
+		retry   bool
+		created bool
+	}{
+		{"auto", ModeAuto, false, true},
+		{"manual", ModeManual, false, true},
+		{"retry", ModeAuto, true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			js, l := &fakeJobStore{}, &fakeLock{}
+			s := &Scheduler{js: js, fs: &fakeFireStore{}, lock: l, logger: log.Get("schedule")}
+			job := &Job{oid: primitive.NewObjectID(), fire: time.Now(), Task: "test", Mode: c.mode}
+
+			s.handover(job, c.retry)
+			if c.created {
+				if len(js.created) != 1 || !js.created[0].Delayed || js.created[0].Id != job.oid {
+					t.Fatalf("job should be persisted as delayed: %+v", js.created)
+				}
+			} else if len(js.delayed) != 1 || js.delayed[0] != job.oid {
+				t.Fatalf("retry should be delayed: %v", js.delayed)
+			}
+			// lock of auto fire is kept, so it is not fired again by other nodes
+			if l.unlocked != 0 {
+				t.Fatal("lock should not be released")
+			}
+		})
+	}
+}
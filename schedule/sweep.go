@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/cuigh/auxo/config"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sweep periodically marks jobs which are still executing after deadline or whose heartbeat is lost as timed out,
+// it is the only way to detect jobs whose runner crashed after accepting them.
+func (s *Scheduler) sweep() {
+	interval := config.GetDuration("skynet.sweep_interval")
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	lost := config.GetDuration("skynet.heartbeat_timeout")
+	if lost <= 0 {
+		lost = 2 * time.Minute
+	}
+	stalled := config.GetDuration("skynet.dispatch_timeout")
+	if stalled <= 0 {
+		stalled = 10 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweepOverdue()
+			s.sweepLost(lost)
+			s.sweepStalled(stalled)
+			s.sweepSlots()
+			s.sweepQueued()
+		case <-s.closer:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) sweepOverdue() {
+	jobs, err := s.js.FetchOverdue(100)
+	if err != nil {
+		s.logger.Errorf("failed to fetch overdue jobs: %s", err)
+		return
+	}
+	s.expire(jobs, "execution timed out")
+}
+
+func (s *Scheduler) sweepLost(timeout time.Duration) {
+	jobs, err := s.js.FetchLost(time.Now().Add(-timeout), 100)
+	if err != nil {
+		s.logger.Errorf("failed to fetch lost jobs: %s", err)
+		return
+	}
+	s.expire(jobs, "runner heartbeat lost")
+}
+
+// sweepStalled fails jobs which are not dispatched long after they were due, e.g. scheduler node crashed after
+// saving them, otherwise they would be counted as active jobs of task forever.
+func (s *Scheduler) sweepStalled(timeout time.Duration) {
+	jobs, err := s.js.FetchStalled(time.Now().Add(-timeout), 100)
+	if err != nil {
+		s.logger.Errorf("failed to fetch stalled jobs: %s", err)
+		return
+	}
+
+	info := "job was not dispatched in time"
+	for _, j := range jobs {
+		ok, err := s.js.Abandon(j.Id, info)
+		if err != nil {
+			s.logger.Errorf("failed to abandon job '%s': %s", j.Id.Hex(), err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		s.logger.Warnf("job '%s' of task '%s' is abandoned: %s", j.Id.Hex(), j.Task, info)
+		id := j.Id.Hex()
+		s.spawn(func() { s.fail(id, store.RetryOnDispatch, info) })
+	}
+}
+
+// sweepSlots releases concurrency slots held by finished jobs in case that releasing was missed, e.g. scheduler
+// node crashed after job was finished, otherwise queued jobs of task would wait forever.
+func (s *Scheduler) sweepSlots() {
+	slots, err := s.ss.FetchAll()
+	if err != nil {
+		s.logger.Errorf("failed to fetch concurrency slots: %s", err)
+		return
+	}
+
+	for _, slot := range slots {
+		j, err := s.js.Find(slot.Job.Hex())
+		if err == mongo.ErrNoDocuments {
+			j = nil // removed after expiration
+		} else if err != nil {
+			s.logger.Errorf("failed to find job '%s': %s", slot.Job.Hex(), err)
+			continue
+		}
+		if j != nil && j.Execute.Status == store.JobStatusUnknown {
+			continue
+		}
+
+		s.logger.Warnf("release concurrency slot of task '%s' held by finished job '%s'", slot.Task, slot.Job.Hex())
+		if err = s.ss.Release(slot.Task, slot.Job); err != nil {
+			s.logger.Errorf("failed to release concurrency slot of job '%s': %s", slot.Job.Hex(), err)
+		}
+	}
+}
+
+// sweepQueued dispatches queued jobs in case that releasing was missed, e.g. scheduler node was restarted.
+func (s *Scheduler) sweepQueued() {
+	tasks, err := s.js.FetchQueuedTasks()
+	if err != nil {
+		s.logger.Errorf("failed to fetch tasks with queued jobs: %s", err)
+		return
+	}
+	for _, t := range tasks {
+		s.release(t)
+	}
+}
+
+func (s *Scheduler) expire(jobs []*store.Job, info string) {
+	for _, j := range jobs {
+		ok, err := s.js.Timeout(j.Id, info)
+		if err != nil {
+			s.logger.Errorf("failed to mark job '%s' as timed out: %s", j.Id.Hex(), err)
+			continue
+		} else if !ok {
+			// finished just now or handled by another node
+			continue
+		}
+
+		s.logger.Warnf("job '%s' of task '%s' timed out: %s", j.Id.Hex(), j.Task, info)
+		j := j
+		s.spawn(func() { s.timeout(j, info) })
+	}
+}
+
+func (s *Scheduler) timeout(j *store.Job, info string) {
+	t, err := s.tf.Find(j.Task)
+	if err == nil && t.Timeout.Cancel {
+		if r := s.cancel(j, t.Runner); !r.Success() {
+			s.logger.Errorf("failed to cancel job '%s': %s", j.Id.Hex(), r.Info)
+		}
+	}
+	s.fail(j.Id.Hex(), store.RetryOnExecute, info)
+}
+
+// cancel asks runner to cancel job, the request is sent to all addresses of runner if the accepted one is unknown.
+func (s *Scheduler) cancel(j *store.Job, runner string) *CallResult {
+	schema, addrs, err := s.resolver.Resolve(runner)
+	if err != nil {
+		return &CallResult{Code: contract.CodeFailed, Info: "failed to resolve runner: " + err.Error()}
+	}
+	caller := s.callers[schema]
+	if caller == nil {
+		return &CallResult{Code: contract.CodeNotSupported, Info: "caller not found: " + schema}
+	}
+	if j.Dispatch.Address != "" {
+		addrs = []string{j.Dispatch.Address}
+	}
+	return caller.Cancel(addrs, j.Id.Hex())
+}
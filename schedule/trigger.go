@@ -0,0 +1,231 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/robfig/cron/v3"
+)
+
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// hashFields holds names and bounds of cron fields for H, day of month stops at 28 so that every month has it.
+var hashFields = []struct {
+	name   string
+	lo, hi int
+}{
+	{"second", 0, 59},
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"dom", 1, 28},
+	{"month", 1, 12},
+	{"dow", 0, 6},
+}
+
+// ParseTrigger parses a cron expression, loc is used if expression has no CRON_TZ=/TZ= prefix,
+// H fields are resolved by seed, see ExpandTrigger.
+func ParseTrigger(expr string, loc *time.Location, seed string) (cron.Schedule, error) {
+	expr, err := ExpandTrigger(expr, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if loc != nil && !strings.HasPrefix(expr, "TZ=") && !strings.HasPrefix(expr, "CRON_TZ=") {
+		expr = "CRON_TZ=" + loc.String() + " " + expr
+	}
+
+	s, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec, ok := s.(*cron.SpecSchedule); ok {
+		wall := *spec
+		wall.Location = time.UTC
+		return &zonedSchedule{wall: &wall, loc: spec.Location}, nil
+	}
+	return s, nil
+}
+
+// ExpandTrigger replaces H fields in cron expression with values hashed from seed(usually task name), so fires
+// of tasks are spread but stable. Forms of H are like Jenkins:
+//
+//   - H: a value in full range of field, e.g. H in minute field means a minute in 0-59
+//   - H(a-b): a value in range a-b
+//   - H/n or H(a-b)/n: every n from a hashed start, e.g. H/15 in minute field may be 7-59/15
+func ExpandTrigger(expr, seed string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	var prefix string
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		i := strings.IndexAny(expr, " \t")
+		if i < 0 {
+			return expr, nil
+		}
+		prefix, expr = expr[:i+1], strings.TrimSpace(expr[i:])
+	}
+	if strings.HasPrefix(expr, "@") || !strings.Contains(expr, "H") {
+		return prefix + expr, nil
+	}
+
+	fields := strings.Fields(expr)
+	offset := 0
+	if len(fields) == 5 {
+		// second field is omitted
+		offset = 1
+	} else if len(fields) != 6 {
+		return "", errors.Format("expected 5 or 6 fields, found %d: %s", len(fields), expr)
+	}
+
+	for i, field := range fields {
+		if !strings.Contains(field, "H") {
+			continue
+		}
+
+		f := hashFields[i+offset]
+		items := strings.Split(field, ",")
+		for j, item := range items {
+			v, err := expandHash(item, f.lo, f.hi, hash(seed+"/"+f.name))
+			if err != nil {
+				return "", err
+			}
+			items[j] = v
+		}
+		fields[i] = strings.Join(items, ",")
+	}
+	return prefix + strings.Join(fields, " "), nil
+}
+
+func expandHash(item string, lo, hi int, h uint32) (string, error) {
+	if !strings.HasPrefix(item, "H") {
+		return item, nil
+	}
+
+	rest := item[1:]
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", errors.Format("invalid hash expression: %s", item)
+		}
+		bounds := strings.SplitN(rest[1:end], "-", 2)
+		if len(bounds) != 2 {
+			return "", errors.Format("invalid hash expression: %s", item)
+		}
+		a, err1 := strconv.Atoi(bounds[0])
+		b, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil || a < lo || b > hi || a > b {
+			return "", errors.Format("invalid range of hash expression: %s", item)
+		}
+		lo, hi, rest = a, b, rest[end+1:]
+	}
+
+	switch {
+	case rest == "":
+		return strconv.Itoa(lo + int(h%uint32(hi-lo+1))), nil
+	case strings.HasPrefix(rest, "/"):
+		step, err := strconv.Atoi(rest[1:])
+		if err != nil || step <= 0 {
+			return "", errors.Format("invalid step of hash expression: %s", item)
+		}
+		n := step
+		if span := hi - lo + 1; n > span {
+			n = span
+		}
+		return fmt.Sprintf("%d-%d/%d", lo+int(h%uint32(n)), hi, step), nil
+	default:
+		return "", errors.Format("invalid hash expression: %s", item)
+	}
+}
+
+// jitter returns a delay within window for fire of task, it is stable so all nodes get the same value.
+func jitter(seed string, fire time.Time, window time.Duration) time.Duration {
+	ms := window.Milliseconds()
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(int64(hash(seed+"@"+strconv.FormatInt(fire.UnixNano(), 10)))%ms) * time.Millisecond
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// LoadLocation returns location of name, empty name means local zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// intervalSchedule fires every interval from anchor.
+type intervalSchedule struct {
+	anchor time.Time
+	every  time.Duration
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.anchor) {
+		return s.anchor
+	}
+	n := t.Sub(s.anchor)/s.every + 1
+	return s.anchor.Add(n * s.every)
+}
+
+// onceSchedule fires only once at the specified time.
+type onceSchedule time.Time
+
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if at := time.Time(s); t.Before(at) {
+		return at
+	}
+	return time.Time{}
+}
+
+// zonedSchedule evaluates cron fields against wall clock of loc, so DST transitions are handled like this:
+//
+//   - fire times inside a gap(spring forward) are shifted forward by the length of the gap
+//   - fire times inside an overlap(fall back) fire only once
+type zonedSchedule struct {
+	wall *cron.SpecSchedule // spec evaluated in UTC, which has no DST
+	loc  *time.Location
+}
+
+func (s *zonedSchedule) Next(t time.Time) time.Time {
+	w := toWall(t.In(s.loc))
+	for i := 0; i < 10; i++ {
+		w = s.wall.Next(w)
+		if w.IsZero() {
+			return w
+		}
+
+		fire := fromWall(w, s.loc)
+		if fire.After(t) {
+			return fire
+		}
+	}
+	return time.Time{}
+}
+
+// toWall converts t to a UTC time with the same wall clock.
+func toWall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWall converts wall clock w to a time in loc, nonexistent wall clock is moved to the instant after the gap.
+func fromWall(w time.Time, loc *time.Location) time.Time {
+	t := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), loc)
+	if !toWall(t).Equal(w) {
+		// time.Date may resolve it with either offset, so choose the later one
+		_, offset := t.Zone()
+		if shifted := w.Add(-time.Duration(offset) * time.Second).In(loc); shifted.After(t) {
+			t = shifted
+		}
+	}
+	return t
+}
@@ -0,0 +1,330 @@
+package schedule
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cuigh/skynet/store"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is unavailable: %s", name, err)
+	}
+	return loc
+}
+
+func TestParseTriggerLocation(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"utc", "0 0 9 * * *", time.UTC, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"task zone", "0 0 9 * * *", shanghai, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"prefix overrides task zone", "CRON_TZ=UTC 0 0 9 * * *", shanghai, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"five fields", "30 9 * * *", shanghai, time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)},
+		{"descriptor", "@daily", shanghai, time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseTrigger(c.expr, c.loc, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(start); !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got.UTC(), c.want)
+			}
+		})
+	}
+}
+
+func TestZonedScheduleDST(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	date := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, ny)
+	}
+
+	cases := []struct {
+		name  string
+		expr  string
+		start time.Time
+		fires []time.Time
+	}{
+		{
+			// 2:30 doesn't exist on 2024-03-10, it is shifted to 3:30 EDT
+			name:  "spring forward",
+			expr:  "0 30 2 * * *",
+			start: date(time.March, 9, 12, 0),
+			fires: []time.Time{
+				time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC),
+				date(time.March, 11, 2, 30),
+			},
+		},
+		{
+			// 1:30 occurs twice on 2024-11-03, it fires only at the first one
+			name:  "fall back",
+			expr:  "0 30 1 * * *",
+			start: date(time.November, 2, 12, 0),
+			fires: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				date(time.November, 4, 1, 30),
+			},
+		},
+		{
+			name:  "hourly across fall back",
+			expr:  "0 0 * * * *",
+			start: time.Date(2024, 11, 3, 4, 30, 0, 0, time.UTC), // 0:30 EDT
+			fires: []time.Time{
+				time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC), // 1:00 EDT
+				time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC), // 2:00 EST
+				time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC), // 3:00 EST
+			},
+		},
+		{
+			name:  "hourly across spring forward",
+			expr:  "0 0 * * * *",
+			start: time.Date(2024, 3, 10, 5, 30, 0, 0, time.UTC), // 0:30 EST
+			fires: []time.Time{
+				time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), // 1:00 EST
+				time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), // 3:00 EDT
+				time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), // 4:00 EDT
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := ParseTrigger(c.expr, ny, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := c.start
+			for i, want := range c.fires {
+				next = s.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("fire %d: got %s, want %s", i, next.In(ny), want.In(ny))
+				}
+			}
+		})
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc != time.Local {
+		t.Fatalf("empty name: got %v, %v", loc, err)
+	}
+	if _, err := LoadLocation("Invalid/Zone"); err == nil {
+		t.Fatal("invalid name: error expected")
+	}
+}
+
+func TestNextFireTimeUsesTaskTimeZone(t *testing.T) {
+	task := &store.Task{Name: "test", TimeZone: "Asia/Shanghai", Triggers: []string{"0 0 9 * * *"}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	got, err := NextFireTime(task, nil, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	task.TimeZone = "Invalid/Zone"
+	if _, err = NextFireTime(task, nil, start); err == nil {
+		t.Fatal("expected error for invalid time zone")
+	}
+}
+
+func TestIntervalSchedule(t *testing.T) {
+	anchor := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s := intervalSchedule{anchor: anchor, every: 90 * time.Second}
+
+	cases := []struct {
+		name  string
+		start time.Time
+		want  time.Time
+	}{
+		{"before anchor", anchor.Add(-time.Hour), anchor},
+		{"at anchor", anchor, anchor.Add(90 * time.Second)},
+		{"between fires", anchor.Add(100 * time.Second), anchor.Add(180 * time.Second)},
+		{"at fire", anchor.Add(180 * time.Second), anchor.Add(270 * time.Second)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := s.Next(c.start); !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestOnceSchedule(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	s := onceSchedule(at)
+
+	cases := []struct {
+		name  string
+		start time.Time
+		want  time.Time
+	}{
+		{"before", at.Add(-time.Second), at},
+		{"at", at, time.Time{}},
+		{"after", at.Add(time.Second), time.Time{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := s.Next(c.start); !got.Equal(c.want) {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestExpandHash(t *testing.T) {
+	cases := []struct {
+		item   string
+		lo, hi int
+		h      uint32
+		want   string
+		err    bool
+	}{
+		{item: "5", lo: 0, hi: 59, h: 7, want: "5"},
+		{item: "H", lo: 0, hi: 59, h: 7, want: "7"},
+		{item: "H", lo: 0, hi: 59, h: 67, want: "7"},
+		{item: "H", lo: 1, hi: 28, h: 30, want: "3"},
+		{item: "H(10-20)", lo: 0, hi: 59, h: 25, want: "13"},
+		{item: "H/15", lo: 0, hi: 59, h: 22, want: "7-59/15"},
+		{item: "H(0-29)/10", lo: 0, hi: 59, h: 13, want: "3-29/10"},
+		{item: "H/100", lo: 0, hi: 59, h: 70, want: "10-59/100"},
+		{item: "H(10-20", lo: 0, hi: 59, err: true},
+		{item: "H(20-10)", lo: 0, hi: 59, err: true},
+		{item: "H(0-70)", lo: 0, hi: 59, err: true},
+		{item: "H(a-b)", lo: 0, hi: 59, err: true},
+		{item: "H/0", lo: 0, hi: 59, err: true},
+		{item: "H/x", lo: 0, hi: 59, err: true},
+		{item: "Hx", lo: 0, hi: 59, err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.item, func(t *testing.T) {
+			got, err := expandHash(c.item, c.lo, c.hi, c.h)
+			if c.err {
+				if err == nil {
+					t.Fatalf("error expected, got %s", got)
+				}
+				return
+			}
+			if err != nil || got != c.want {
+				t.Fatalf("got (%s, %v), want %s", got, err, c.want)
+			}
+		})
+	}
+}
+
+func TestExpandTrigger(t *testing.T) {
+	field := func(name string, lo, hi int) string {
+		return strconv.Itoa(lo + int(hash("task/"+name)%uint32(hi-lo+1)))
+	}
+	second, minute, hour, dom := field("second", 0, 59), field("minute", 0, 59), field("hour", 0, 23), field("dom", 1, 28)
+
+	cases := []struct {
+		name string
+		expr string
+		want string
+		err  bool
+	}{
+		{name: "no hash", expr: "0 */5 * * * *", want: "0 */5 * * * *"},
+		{name: "descriptor", expr: "@daily", want: "@daily"},
+		{name: "five fields", expr: "H H * * *", want: minute + " " + hour + " * * *"},
+		{name: "six fields", expr: "H H H * * *", want: second + " " + minute + " " + hour + " * * *"},
+		{name: "day of month", expr: "0 0 H * *", want: "0 0 " + dom + " * *"},
+		{name: "list", expr: "H,30 * * * *", want: minute + ",30 * * * *"},
+		{name: "zone prefix", expr: "CRON_TZ=Asia/Shanghai H 9 * * *", want: "CRON_TZ=Asia/Shanghai " + minute + " 9 * * *"},
+		{name: "spaces", expr: "  H  9 * * *  ", want: minute + " 9 * * *"},
+		{name: "too few fields", expr: "H * *", err: true},
+		{name: "invalid hash", expr: "H(1-2 * * * *", err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ExpandTrigger(c.expr, "task")
+			if c.err {
+				if err == nil {
+					t.Fatalf("error expected, got %s", got)
+				}
+				return
+			}
+			if err != nil || got != c.want {
+				t.Fatalf("got (%s, %v), want %s", got, err, c.want)
+			}
+		})
+	}
+}
+
+func TestParseTriggerSpreadsHash(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minutes := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		s, err := ParseTrigger("H * * * *", time.UTC, "task-"+strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fire := s.Next(start)
+		if fire.Sub(start) > time.Hour || fire.Second() != 0 {
+			t.Fatalf("unexpected fire time: %s", fire)
+		}
+		minutes[fire.Minute()] = true
+	}
+	if len(minutes) < 5 {
+		t.Fatalf("fires of tasks are not spread: %v", minutes)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	fire := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	if d := jitter("task", fire, 0); d != 0 {
+		t.Fatalf("zero window: got %s", d)
+	}
+
+	window := 30 * time.Second
+	for i := 0; i < 100; i++ {
+		f := fire.Add(time.Duration(i) * time.Minute)
+		d := jitter("task", f, window)
+		if d < 0 || d >= window {
+			t.Fatalf("jitter %s is out of window %s", d, window)
+		}
+		if d != jitter("task", f, window) {
+			t.Fatal("jitter is not stable")
+		}
+	}
+}
+
+func TestTaskItemDue(t *testing.T) {
+	task := &store.Task{Name: "test", TimeZone: "UTC", Triggers: []string{"0 * * * * *"}}
+	task.Trigger.Jitter = 30
+	item, err := NewItem(task, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		item.next(now)
+		want := item.fire.Add(jitter(task.Name, item.fire, 30*time.Second))
+		if !item.due().Equal(want) {
+			t.Fatalf("got %s, want %s", item.due(), want)
+		}
+		item.dispatched()
+		now = item.fire
+	}
+
+	item.resume(now.Add(time.Hour))
+	if want := item.fire.Add(jitter(task.Name, item.fire, 30*time.Second)); !item.due().Equal(want) {
+		t.Fatalf("after resume: got %s, want %s", item.due(), want)
+	}
+}
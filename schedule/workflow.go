@@ -0,0 +1,247 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"github.com/cuigh/skynet/contract"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WorkflowCaller starts a run of workflow for tasks whose runner is `workflow://{name}`,
+// the triggering job is completed when the run is finished.
+type WorkflowCaller struct {
+	s *Scheduler
+}
+
+func (c WorkflowCaller) Call(addrs []string, j *Job) *CallResult {
+	name := strings.TrimPrefix(addrs[0], "workflow://")
+	if _, err := c.s.startRun(name, j.Id); err != nil {
+		return &CallResult{Code: contract.CodeFailed, Info: err.Error()}
+	}
+	return &CallResult{Address: addrs[0]}
+}
+
+func (c WorkflowCaller) Cancel(addrs []string, id string) *CallResult {
+	return &CallResult{Code: contract.CodeNotSupported, Info: "workflow run should be stopped on workflow page"}
+}
+
+func (c WorkflowCaller) Split(addrs []string, j *Job) *contract.SplitResult {
+	return &contract.SplitResult{Code: contract.CodeNotSupported, Info: "not supported"}
+}
+
+// ExecuteWorkflow starts a run of workflow manually.
+func (s *Scheduler) ExecuteWorkflow(name string) (string, error) {
+	run, err := s.startRun(name, "")
+	if err != nil {
+		return "", err
+	}
+	return run.Id.Hex(), nil
+}
+
+// StopRun skips pending nodes of a run and cancels running node jobs.
+func (s *Scheduler) StopRun(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	run, err := s.rs.Stop(oid)
+	if err != nil {
+		return err
+	} else if run == nil {
+		return errors.Format("workflow run '%s' is already finished or not found", id)
+	}
+
+	for _, n := range run.Nodes {
+		if n.Status == store.NodeStatusRunning && n.Job != "" {
+			if err = s.Cancel(n.Job); err != nil {
+				s.logger.Warnf("failed to cancel job '%s' of workflow run '%s': %s", n.Job, id, err)
+			}
+		}
+	}
+	s.advance(run)
+	return nil
+}
+
+func (s *Scheduler) startRun(name, job string) (*store.WorkflowRun, error) {
+	w, err := s.ws.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Nodes) == 0 {
+		return nil, errors.Format("workflow '%s' has no nodes", name)
+	}
+
+	run := &store.WorkflowRun{
+		Id:        primitive.NewObjectID(),
+		Workflow:  w.Name,
+		Job:       job,
+		Status:    store.RunStatusRunning,
+		StartTime: store.Time(time.Now()),
+	}
+	for _, n := range w.Nodes {
+		run.Nodes = append(run.Nodes, &store.RunNode{WorkflowNode: *n, Status: store.NodeStatusPending})
+	}
+	if err = s.rs.Create(run); err != nil {
+		return nil, err
+	}
+
+	s.spawn(func() { s.advance(run) })
+	return run, nil
+}
+
+// advance dispatches nodes whose upstream nodes are all finished, and completes the run if no nodes remain.
+func (s *Scheduler) advance(run *store.WorkflowRun) {
+	nodes := make(map[string]*store.RunNode)
+	for _, n := range run.Nodes {
+		nodes[n.Id] = n
+	}
+
+	remains := 0
+	for _, n := range run.Nodes {
+		switch n.Status {
+		case store.NodeStatusRunning:
+			remains++
+		case store.NodeStatusPending:
+			remains++
+			if !run.Stopped && ready(n, nodes) {
+				if ok, err := s.rs.StartNode(run.Id, n.Id); err != nil {
+					s.logger.Errorf("failed to start node '%s' of workflow run '%s': %s", n.Id, run.Id.Hex(), err)
+				} else if ok {
+					n := n
+					s.spawn(func() { s.dispatchNode(run, n) })
+				}
+			}
+		}
+	}
+
+	if remains == 0 {
+		s.finishRun(run)
+	}
+}
+
+// ready returns true if all upstream nodes of n succeeded or failed with Continue policy.
+func ready(n *store.RunNode, nodes map[string]*store.RunNode) bool {
+	for _, id := range n.Depends {
+		up := nodes[id]
+		if up == nil {
+			continue
+		}
+		if up.Status != store.NodeStatusSuccess &&
+			!(up.Status == store.NodeStatusFailed && up.OnFailure == store.NodeFailureContinue) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scheduler) dispatchNode(run *store.WorkflowRun, n *store.RunNode) {
+	t, err := s.tf.Find(n.Task)
+	if err != nil {
+		s.logger.Errorf("failed to find task '%s' of workflow run '%s': %s", n.Task, run.Id.Hex(), err)
+		s.finishNode(run.Id.Hex(), n.Id, false)
+		return
+	}
+
+	job := NewJob(t, nil, ModeWorkflow, time.Now())
+	job.run, job.node = run.Id.Hex(), n.Id
+	if err = s.rs.SetNodeJob(run.Id, n.Id, job.Id); err != nil {
+		s.logger.Errorf("failed to set job of node '%s' in workflow run '%s': %s", n.Id, run.Id.Hex(), err)
+	}
+	s.pool.Submit(job, false)
+}
+
+// finishNode records final result of a node and dispatches downstream nodes.
+func (s *Scheduler) finishNode(id, node string, success bool) {
+	run, err := s.rs.Find(id)
+	if err != nil {
+		s.logger.Errorf("failed to find workflow run '%s': %s", id, err)
+		return
+	}
+
+	var n *store.RunNode
+	for _, rn := range run.Nodes {
+		if rn.Id == node {
+			n = rn
+			break
+		}
+	}
+	if n == nil || n.Status != store.NodeStatusRunning {
+		return
+	}
+
+	if !success && !run.Stopped && n.OnFailure == store.NodeFailureRetry && n.Attempts <= n.Retries {
+		if err = s.rs.RetryNode(run.Id, node); err != nil {
+			s.logger.Errorf("failed to retry node '%s' of workflow run '%s': %s", node, id, err)
+		} else {
+			s.logger.Infof("node '%s' of workflow run '%s' failed(attempt: %d), retry it", node, id, n.Attempts)
+			s.dispatchNode(run, n)
+			return
+		}
+	}
+
+	status := store.NodeStatusSuccess
+	if !success {
+		status = store.NodeStatusFailed
+	}
+	if run, err = s.rs.FinishNode(run.Id, node, status); err != nil {
+		s.logger.Errorf("failed to finish node '%s' of workflow run '%s': %s", node, id, err)
+		return
+	}
+
+	if !success && n.OnFailure != store.NodeFailureContinue {
+		if run, err = s.rs.Stop(run.Id); err != nil {
+			s.logger.Errorf("failed to stop workflow run '%s': %s", id, err)
+			return
+		}
+	}
+	s.advance(run)
+}
+
+// finishRun sets final status of run and completes the job which triggered it.
+func (s *Scheduler) finishRun(run *store.WorkflowRun) {
+	var failed []string
+	for _, n := range run.Nodes {
+		if n.Status == store.NodeStatusFailed && n.OnFailure != store.NodeFailureContinue {
+			failed = append(failed, n.Id)
+		}
+	}
+
+	status, info := store.RunStatusSuccess, ""
+	if len(failed) > 0 || run.Stopped {
+		status = store.RunStatusFailed
+		if len(failed) > 0 {
+			info = fmt.Sprintf("nodes failed: %s", strings.Join(failed, ", "))
+		} else {
+			info = "workflow run was stopped"
+		}
+	}
+
+	ok, err := s.rs.Finish(run.Id, status)
+	if err != nil {
+		s.logger.Errorf("failed to finish workflow run '%s': %s", run.Id.Hex(), err)
+		return
+	} else if !ok || run.Job == "" {
+		return
+	}
+
+	var j *store.Job
+	if status == store.RunStatusSuccess {
+		j, err = s.js.ModifyExecute(run.Job, 0, store.JobStatusSuccess, "", time.Time(run.StartTime), time.Now())
+	} else {
+		j, err = s.js.ModifyExecute(run.Job, 0, store.JobStatusFailed, info, time.Time(run.StartTime), time.Now())
+	}
+	if err != nil {
+		s.logger.Errorf("failed to update job execute info: %s", err)
+	} else if j == nil {
+		s.logger.Warnf("triggering job '%s' of workflow run '%s' was settled already", run.Job, run.Id.Hex())
+	} else if status == store.RunStatusFailed {
+		s.fail(run.Job, store.RetryOnExecute, info)
+	} else {
+		s.finish(j, true)
+	}
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cuigh/auxo/log"
+	"github.com/cuigh/skynet/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func runNode(id string, status int32, onFailure int32, depends ...string) *store.RunNode {
+	return &store.RunNode{
+		WorkflowNode: store.WorkflowNode{Id: id, Depends: depends, OnFailure: onFailure},
+		Status:       status,
+	}
+}
+
+func TestReady(t *testing.T) {
+	cases := []struct {
+		name string
+		up   *store.RunNode
+		want bool
+	}{
+		{"upstream pending", runNode("a", store.NodeStatusPending, store.NodeFailureStop), false},
+		{"upstream running", runNode("a", store.NodeStatusRunning, store.NodeFailureStop), false},
+		{"upstream succeeded", runNode("a", store.NodeStatusSuccess, store.NodeFailureStop), true},
+		{"upstream failed", runNode("a", store.NodeStatusFailed, store.NodeFailureStop), false},
+		{"upstream failed with retry", runNode("a", store.NodeStatusFailed, store.NodeFailureRetry), false},
+		{"upstream failed with continue", runNode("a", store.NodeStatusFailed, store.NodeFailureContinue), true},
+		{"upstream skipped", runNode("a", store.NodeStatusSkipped, store.NodeFailureStop), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n := runNode("b", store.NodeStatusPending, store.NodeFailureStop, "a")
+			nodes := map[string]*store.RunNode{"a": c.up, "b": n}
+			if got := ready(n, nodes); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	root := runNode("a", store.NodeStatusPending, store.NodeFailureStop)
+	if !ready(root, map[string]*store.RunNode{"a": root}) {
+		t.Fatal("node without upstream should be ready")
+	}
+}
+
+// fakeRunStore records nodes started and status of finished run, StartNode always returns false so that
+// nodes are not dispatched.
+type fakeRunStore struct {
+	store.RunStore
+	started  []string
+	finished []int32
+}
+
+func (s *fakeRunStore) StartNode(_ primitive.ObjectID, node string) (bool, error) {
+	s.started = append(s.started, node)
+	return false, nil
+}
+
+func (s *fakeRunStore) Finish(_ primitive.ObjectID, status int32) (bool, error) {
+	s.finished = append(s.finished, status)
+	return true, nil
+}
+
+func TestAdvance(t *testing.T) {
+	cases := []struct {
+		name     string
+		stopped  bool
+		nodes    []*store.RunNode
+		started  []string
+		finished []int32
+	}{
+		{
+			name: "start roots",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusPending, store.NodeFailureStop),
+				runNode("b", store.NodeStatusPending, store.NodeFailureStop),
+				runNode("c", store.NodeStatusPending, store.NodeFailureStop, "a", "b"),
+			},
+			started: []string{"a", "b"},
+		},
+		{
+			name: "wait for all upstream nodes",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusSuccess, store.NodeFailureStop),
+				runNode("b", store.NodeStatusRunning, store.NodeFailureStop),
+				runNode("c", store.NodeStatusPending, store.NodeFailureStop, "a", "b"),
+				runNode("d", store.NodeStatusPending, store.NodeFailureStop, "a"),
+			},
+			started: []string{"d"},
+		},
+		{
+			name: "continue after failure",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusFailed, store.NodeFailureContinue),
+				runNode("b", store.NodeStatusPending, store.NodeFailureStop, "a"),
+			},
+			started: []string{"b"},
+		},
+		{
+			name:    "stopped run starts nothing",
+			stopped: true,
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusRunning, store.NodeFailureStop),
+				runNode("b", store.NodeStatusPending, store.NodeFailureStop),
+			},
+		},
+		{
+			name: "all succeeded",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusSuccess, store.NodeFailureStop),
+				runNode("b", store.NodeStatusSuccess, store.NodeFailureStop, "a"),
+			},
+			finished: []int32{store.RunStatusSuccess},
+		},
+		{
+			name: "failed node",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusFailed, store.NodeFailureStop),
+				runNode("b", store.NodeStatusSkipped, store.NodeFailureStop, "a"),
+			},
+			finished: []int32{store.RunStatusFailed},
+		},
+		{
+			name: "failed node with continue",
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusFailed, store.NodeFailureContinue),
+				runNode("b", store.NodeStatusSuccess, store.NodeFailureStop, "a"),
+			},
+			finished: []int32{store.RunStatusSuccess},
+		},
+		{
+			name:    "stopped",
+			stopped: true,
+			nodes: []*store.RunNode{
+				runNode("a", store.NodeStatusSuccess, store.NodeFailureStop),
+				runNode("b", store.NodeStatusSkipped, store.NodeFailureStop, "a"),
+			},
+			finished: []int32{store.RunStatusFailed},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rs := &fakeRunStore{}
+			s := &Scheduler{rs: rs, logger: log.Get("schedule")}
+			s.advance(&store.WorkflowRun{Id: primitive.NewObjectID(), Stopped: c.stopped, Nodes: c.nodes})
+
+			sort.Strings(rs.started)
+			if !reflect.DeepEqual(rs.started, c.started) {
+				t.Fatalf("started: got %v, want %v", rs.started, c.started)
+			}
+			if !reflect.DeepEqual(rs.finished, c.finished) {
+				t.Fatalf("finished: got %v, want %v", rs.finished, c.finished)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Calendar restricts fire times of tasks which reference it.
+// A day is available if it is in Included, or neither its weekday is in Weekdays nor it is in Excluded,
+// and fire times inside Blackouts are always excluded.
+type Calendar struct {
+	Name        string           `json:"name" bson:"_id" valid:"required"`
+	Description string           `json:"desc,omitempty" bson:"desc,omitempty"`
+	TimeZone    string           `json:"timezone,omitempty" bson:"timezone,omitempty"` // zone of dates, empty means local zone
+	Weekdays    []int32          `json:"weekdays,omitempty" bson:"weekdays,omitempty"` // excluded weekdays, 0-Sunday
+	Excluded    []string         `json:"excluded,omitempty" bson:"excluded,omitempty"` // excluded dates, format: yyyy-MM-dd
+	Included    []string         `json:"included,omitempty" bson:"included,omitempty"` // included dates which override Weekdays
+	Blackouts   []*CalendarRange `json:"blackouts,omitempty" bson:"blackouts,omitempty"`
+	Maintainers []string         `json:"maintainers" bson:"maintainers"`
+	ModifyTime  Time             `json:"modify_time" bson:"modify_time"`
+}
+
+// CalendarRange is a time range [Start, End) such as maintenance window or freeze period.
+type CalendarRange struct {
+	Start  Time   `json:"start" bson:"start"`
+	End    Time   `json:"end" bson:"end"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+type CalendarStore interface {
+	Find(name string) (*Calendar, error)
+	Delete(name string) error
+	Create(c *Calendar) error
+	Modify(c *Calendar) error
+	Search(name string, pageIndex, pageSize int64) (calendars []*Calendar, total int64, err error)
+	// FetchMany returns calendars of names as a map, names which don't exist are ignored.
+	FetchMany(names []string) (map[string]*Calendar, error)
+	GetState() (modify time.Time, count int64, err error)
+}
+
+type calendarStore struct {
+	c *mongo.Collection
+}
+
+func NewCalendarStore(db *mongo.Database) CalendarStore {
+	return &calendarStore{
+		c: db.Collection("calendar"),
+	}
+}
+
+func (s *calendarStore) Find(name string) (*Calendar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := s.c.FindOne(ctx, bson.M{"_id": name})
+	c := &Calendar{}
+	if err := r.Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *calendarStore) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.c.DeleteOne(ctx, bson.M{"_id": name})
+	if err == nil && r.DeletedCount == 0 {
+		return errors.Format("can't find calendar '%s'", name)
+	}
+	return err
+}
+
+func (s *calendarStore) Create(c *Calendar) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.ModifyTime = Time(time.Now())
+	_, err := s.c.InsertOne(ctx, c)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.Format("日历 %s 已经存在", c.Name)
+	}
+	return err
+}
+
+func (s *calendarStore) Modify(c *Calendar) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.ModifyTime = Time(time.Now())
+	r, err := s.c.ReplaceOne(ctx, bson.M{"_id": c.Name}, c)
+	if err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return errors.Format("can't find calendar '%s'", c.Name)
+	}
+	return nil
+}
+
+func (s *calendarStore) Search(name string, pageIndex, pageSize int64) (calendars []*Calendar, total int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if name != "" {
+		filter["_id"] = name
+	}
+
+	// fetch total count
+	total, err = s.c.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSkip(pageSize * (pageIndex - 1)).SetLimit(pageSize).SetSort(bson.M{"_id": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &calendars)
+	if err != nil {
+		return nil, 0, err
+	}
+	return calendars, total, nil
+}
+
+func (s *calendarStore) FetchMany(names []string) (map[string]*Calendar, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cur, err := s.c.Find(ctx, bson.M{"_id": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var calendars []*Calendar
+	if err = cur.All(ctx, &calendars); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]*Calendar, len(calendars))
+	for _, c := range calendars {
+		m[c.Name] = c
+	}
+	return m, nil
+}
+
+func (s *calendarStore) GetState() (modify time.Time, count int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// count
+	count, err = s.c.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return
+	}
+
+	// modify time
+	r := s.c.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"modify_time": -1}))
+	c := struct {
+		ModifyTime time.Time `bson:"modify_time"`
+	}{}
+	if err = r.Decode(&c); err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	modify = c.ModifyTime
+	return
+}
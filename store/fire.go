@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fire records fire time of the latest auto job of task. Unlike jobs it never expires, so misfires of a task can
+// still be found after a long downtime.
+type Fire struct {
+	Task string `json:"task" bson:"_id"`
+	Time Time   `json:"time" bson:"time"`
+}
+
+type FireStore interface {
+	// Record updates last fire time of task if fire is later than the recorded one.
+	Record(task string, fire time.Time) error
+	// GetLastFireTimes returns last fire times of tasks, tasks which never fired are not included.
+	GetLastFireTimes(tasks []string) (map[string]time.Time, error)
+}
+
+type fireStore struct {
+	c *mongo.Collection
+}
+
+func NewFireStore(db *mongo.Database) FireStore {
+	return &fireStore{
+		c: db.Collection("fire"),
+	}
+}
+
+func (s *fireStore) Record(task string, fire time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Update().SetUpsert(true)
+	_, err := s.c.UpdateByID(ctx, task, bson.M{"$max": bson.M{"time": fire}}, opts)
+	return err
+}
+
+func (s *fireStore) GetLastFireTimes(tasks []string) (map[string]time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := s.c.Find(ctx, bson.M{"_id": bson.M{"$in": tasks}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var fires []*Fire
+	if err = cur.All(ctx, &fires); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]time.Time, len(fires))
+	for _, f := range fires {
+		m[f.Task] = time.Time(f.Time)
+	}
+	return m, nil
+}
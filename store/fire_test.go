@@ -0,0 +1,29 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFireRecord(t *testing.T) {
+	s := NewFireStore(testDB(t))
+
+	fire := time.Now().Truncate(time.Second)
+	for _, f := range []time.Time{fire.Add(-time.Minute), fire, fire.Add(-time.Hour)} {
+		if err := s.Record("test", f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Record("other", fire); err != nil {
+		t.Fatal(err)
+	}
+
+	lasts, err := s.GetLastFireTimes([]string{"test", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// earlier fires don't overwrite the latest one
+	if len(lasts) != 1 || !lasts["test"].Equal(fire) {
+		t.Fatalf("unexpected last fire times: %v", lasts)
+	}
+}
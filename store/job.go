@@ -12,33 +12,107 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	JobStatusUnknown int32 = iota
+	JobStatusSuccess
+	JobStatusFailed
+	JobStatusTimeout
+	JobStatusCancelled
+	JobStatusSkipped
+)
+
 type Job struct {
 	Id        primitive.ObjectID `json:"id" bson:"_id"`
 	Task      string             `json:"task" bson:"task"`
 	Handler   string             `json:"handler" bson:"handler"`
 	Scheduler string             `json:"scheduler" bson:"scheduler"`
-	Mode      int32              `json:"mode" bson:"mode"` // 0-Auto, 1-Manual
+	Mode      int32              `json:"mode" bson:"mode"` // 0-Auto, 1-Manual, 2-Workflow
 	Args      data.Options       `json:"args" bson:"args"`
 	FireTime  Time               `json:"fire_time" bson:"fire_time"`
-	Dispatch  struct {
-		Status int32  `json:"status" bson:"status"` // 0-Unknown，1-Success，2-Failed
-		Time   *Time  `json:"time,omitempty" bson:"time,omitempty"`
-		Error  string `json:"error,omitempty" bson:"error,omitempty"`
-	} `json:"dispatch" bson:"dispatch"`
-	Execute struct {
-		Status    int32  `json:"status" bson:"status"` // 0-Unknown，1-Success，2-Failed
-		Error     string `json:"error,omitempty" bson:"error,omitempty"`
-		StartTime *Time  `json:"start_time,omitempty" bson:"start_time,omitempty"`
-		EndTime   *Time  `json:"end_time,omitempty" bson:"end_time,omitempty"`
-	} `json:"execute" bson:"execute"`
+	Attempt   int32              `json:"attempt,omitempty" bson:"attempt,omitempty"`   // current attempt, starts from 1
+	Attempts  []*JobAttempt      `json:"attempts,omitempty" bson:"attempts,omitempty"` // previous attempts
+	Parent    string             `json:"parent,omitempty" bson:"parent,omitempty"`     // id of parent job for batch job
+	Batch     string             `json:"batch,omitempty" bson:"batch,omitempty"`       // id of batch for batch job
+	Batches   *JobBatches        `json:"batches,omitempty" bson:"batches,omitempty"`   // statistics of batch jobs for parent job
+	Run       string             `json:"run,omitempty" bson:"run,omitempty"`           // id of workflow run for workflow node job
+	Node      string             `json:"node,omitempty" bson:"node,omitempty"`         // id of workflow node for workflow node job
+	Queued    bool               `json:"queued,omitempty" bson:"queued,omitempty"`     // waiting for running jobs of task to finish
+	Delayed   bool               `json:"delayed,omitempty" bson:"delayed,omitempty"`   // waiting for fire time, for jobs submitted with a future fire time
+	Dispatch  JobDispatch        `json:"dispatch" bson:"dispatch"`
+	Execute   JobExecute         `json:"execute" bson:"execute"`
+}
+
+type JobDispatch struct {
+	Status  int32  `json:"status" bson:"status"` // 0-Unknown，1-Success，2-Failed，4-Cancelled，5-Skipped
+	Time    *Time  `json:"time,omitempty" bson:"time,omitempty"`
+	Error   string `json:"error,omitempty" bson:"error,omitempty"`
+	Address string `json:"address,omitempty" bson:"address,omitempty"` // address of runner which accepted the job
+	Due     *Time  `json:"due,omitempty" bson:"due,omitempty"`         // time when job is expected to be dispatched
+}
+
+type JobExecute struct {
+	Status    int32  `json:"status" bson:"status"` // 0-Unknown，1-Success，2-Failed，3-Timeout，4-Cancelled，5-Skipped
+	Error     string `json:"error,omitempty" bson:"error,omitempty"`
+	StartTime *Time  `json:"start_time,omitempty" bson:"start_time,omitempty"`
+	EndTime   *Time  `json:"end_time,omitempty" bson:"end_time,omitempty"`
+	Deadline  *Time  `json:"deadline,omitempty" bson:"deadline,omitempty"`
+	Heartbeat *Time  `json:"heartbeat,omitempty" bson:"heartbeat,omitempty"` // last heartbeat time
+	Runner    string `json:"runner,omitempty" bson:"runner,omitempty"`       // instance of runner which is executing the job
+}
+
+type JobBatches struct {
+	Total   int32 `json:"total" bson:"total"`
+	Success int32 `json:"success" bson:"success"`
+	Failed  int32 `json:"failed" bson:"failed"`
+}
+
+type JobAttempt struct {
+	Number   int32       `json:"number" bson:"number"`
+	Dispatch JobDispatch `json:"dispatch" bson:"dispatch"`
+	Execute  JobExecute  `json:"execute" bson:"execute"`
 }
 
 type JobStore interface {
 	Find(id string) (*Job, error)
 	Search(task string, mode int32, dispatchStatus, executeStatus int32, pageIndex, pageSize int64) (jobs []*Job, total int64, err error)
 	Create(job *Job) error
-	ModifyDispatch(id string, success bool, error string) error
-	ModifyExecute(id string, success bool, error string, start, end time.Time) error
+	ModifyDispatch(id string, success bool, error, address string, deadline time.Time) error
+	ModifyExecute(id string, attempt, status int32, error string, start, end time.Time) (*Job, error)
+	SetBatches(id string, total int32) error
+	SettleBatch(parent string, success bool) (*Job, error)
+	FetchChildren(parent string) ([]*Job, error)
+	Heartbeat(id string, runner string) error
+	FetchOverdue(limit int64) ([]*Job, error)
+	FetchLost(before time.Time, limit int64) ([]*Job, error)
+	// FetchStalled returns jobs which are still not dispatched although they were due before the specified time.
+	FetchStalled(before time.Time, limit int64) ([]*Job, error)
+	// Abandon marks dispatch of an undispatched job as failed, it returns false if job was dispatched meanwhile.
+	Abandon(id primitive.ObjectID, error string) (bool, error)
+	Timeout(id primitive.ObjectID, error string) (bool, error)
+	// Reattempt archives current attempt of job, next attempt is expected to be dispatched at due.
+	Reattempt(job *Job, due time.Time) (bool, error)
+	GetLastFireTimes(tasks []string) (map[string]time.Time, error)
+	// GetLastJobs returns the latest auto job of each task.
+	GetLastJobs(tasks []string) (map[string]*Job, error)
+	// FetchActive returns unfinished top-level jobs of task, only jobs created before the specified one are returned if before is not zero.
+	FetchActive(task string, before primitive.ObjectID) ([]*Job, error)
+	Skip(id primitive.ObjectID, error string) error
+	Queue(id primitive.ObjectID) error
+	// FetchQueued returns the earliest queued job of task, it returns nil if queue is empty.
+	FetchQueued(task string) (*Job, error)
+	// Dequeue removes job from queue and returns it, it returns nil if job is not queued.
+	Dequeue(id primitive.ObjectID) (*Job, error)
+	FetchQueuedTasks() ([]string, error)
+	// FetchDue returns delayed jobs whose fire time is not after now.
+	FetchDue(now time.Time, limit int64) ([]*Job, error)
+	// NextDue returns the earliest fire time of delayed jobs, it returns zero time if there is none.
+	NextDue() (time.Time, error)
+	// Claim takes delayed job for the scheduler node, it returns false if job was claimed by another node or cancelled.
+	Claim(id primitive.ObjectID, scheduler string) (bool, error)
+	// CancelDelayed cancels a delayed job before it is dispatched, it returns false if job is not delayed.
+	CancelDelayed(id primitive.ObjectID) (bool, error)
+	// Delay turns a saved job into a delayed one, so it is dispatched by any node when fire time is due.
+	Delay(id primitive.ObjectID) error
 	CreateIndexes(ctx context.Context) error
 	Count(ctx context.Context) (int64, error)
 }
@@ -115,7 +189,8 @@ func (s *jobStore) Create(job *Job) error {
 	return err
 }
 
-func (s *jobStore) ModifyDispatch(id string, success bool, error string) error {
+// ModifyDispatch updates dispatch result, deadline is ignored if it is zero.
+func (s *jobStore) ModifyDispatch(id string, success bool, error, address string, deadline time.Time) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -129,6 +204,12 @@ func (s *jobStore) ModifyDispatch(id string, success bool, error string) error {
 		"dispatch.error":  error,
 		"dispatch.time":   time.Now(),
 	}
+	if address != "" {
+		update["dispatch.address"] = address
+	}
+	if !deadline.IsZero() {
+		update["execute.deadline"] = deadline
+	}
 	r, err := s.c.UpdateByID(ctx, oid, bson.M{"$set": update})
 	if err != nil {
 		return err
@@ -138,35 +219,519 @@ func (s *jobStore) ModifyDispatch(id string, success bool, error string) error {
 	return nil
 }
 
-func (s *jobStore) ModifyExecute(id string, success bool, error string, start, end time.Time) error {
+// ModifyExecute updates execute result of a running job and returns the modified job, attempt is ignored if it is 0.
+// It returns nil if job was settled already or attempt is stale, so a result is never handled twice.
+func (s *jobStore) ModifyExecute(id string, attempt, status int32, error string, start, end time.Time) (*Job, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	filter := bson.M{"_id": oid, "execute.status": JobStatusUnknown}
+	if attempt == 1 {
+		// jobs created before retry was supported have no attempt field
+		filter["attempt"] = bson.M{"$in": bson.A{attempt, nil}}
+	} else if attempt > 1 {
+		filter["attempt"] = attempt
+	}
 	update := bson.M{
-		"execute.status":     s.status(success),
+		"execute.status":     status,
 		"execute.error":      error,
 		"execute.start_time": start,
 		"execute.end_time":   end,
 	}
-	r, err := s.c.UpdateByID(ctx, oid, bson.M{"$set": update})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	r := s.c.FindOneAndUpdate(ctx, filter, bson.M{"$set": update}, opts)
+	j := &Job{}
+	if err = r.Decode(j); err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// SetBatches initializes batch statistics of parent job.
+func (s *jobStore) SetBatches(id string, total int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.c.UpdateByID(ctx, oid, bson.M{"$set": bson.M{"batches": &JobBatches{Total: total}}})
+	return err
+}
+
+// SettleBatch counts a finished batch job and returns the modified parent job.
+func (s *jobStore) SettleBatch(parent string, success bool) (*Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	field := "batches.failed"
+	if success {
+		field = "batches.success"
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	r := s.c.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$inc": bson.M{field: 1}}, opts)
+	j := &Job{}
+	if err = r.Decode(j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FetchChildren returns batch jobs of parent job.
+func (s *jobStore) FetchChildren(parent string) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := s.c.Find(ctx, bson.M{"parent": parent}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+// Heartbeat records heartbeat of an executing job.
+func (s *jobStore) Heartbeat(id string, runner string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"execute.heartbeat": time.Now()}
+	if runner != "" {
+		update["execute.runner"] = runner
+	}
+	r, err := s.c.UpdateOne(ctx, bson.M{"_id": oid, "execute.status": JobStatusUnknown}, bson.M{"$set": update})
 	if err != nil {
 		return err
 	} else if r.MatchedCount == 0 {
-		return errors.Format("can't find job '%s'", id)
+		return errors.Format("can't find executing job '%s'", id)
 	}
 	return nil
 }
 
+// FetchLost returns executing jobs whose last heartbeat is before the specified time.
+func (s *jobStore) FetchLost(before time.Time, limit int64) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"execute.status":    JobStatusUnknown,
+		"execute.heartbeat": bson.M{"$lt": before},
+	}
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"execute.heartbeat": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+func (s *jobStore) FetchStalled(before time.Time, limit int64) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"dispatch.status": JobStatusUnknown,
+		"dispatch.due":    bson.M{"$lt": before},
+		"execute.status":  JobStatusUnknown,
+		"queued":          bson.M{"$ne": true},
+		"delayed":         bson.M{"$ne": true},
+	}
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"dispatch.due": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+func (s *jobStore) Abandon(id primitive.ObjectID, error string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "dispatch.status": JobStatusUnknown, "execute.status": JobStatusUnknown}
+	update := bson.M{
+		"dispatch.status": JobStatusFailed,
+		"dispatch.error":  error,
+		"dispatch.time":   time.Now(),
+	}
+	r, err := s.c.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+// FetchOverdue returns jobs which are still executing after deadline.
+func (s *jobStore) FetchOverdue(limit int64) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"execute.status":   JobStatusUnknown,
+		"execute.deadline": bson.M{"$lt": time.Now()},
+	}
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"execute.deadline": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+// Timeout marks job as timed out, it returns false if job was already finished or marked by others.
+func (s *jobStore) Timeout(id primitive.ObjectID, error string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "execute.status": JobStatusUnknown}
+	update := bson.M{
+		"execute.status":   JobStatusTimeout,
+		"execute.error":    error,
+		"execute.end_time": time.Now(),
+	}
+	r, err := s.c.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+// Reattempt archives current attempt of job and resets its status for next attempt.
+// It returns false if the job was already reattempted by others.
+func (s *jobStore) Reattempt(job *Job, due time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": job.Id}
+	number := job.Attempt
+	if number == 0 {
+		// jobs created before retry was supported
+		filter["attempt"] = bson.M{"$exists": false}
+		number = 1
+	} else {
+		filter["attempt"] = number
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"attempt":  number + 1,
+			"dispatch": JobDispatch{Due: (*Time)(&due)},
+			"execute":  JobExecute{},
+		},
+		"$push": bson.M{
+			"attempts": &JobAttempt{Number: number, Dispatch: job.Dispatch, Execute: job.Execute},
+		},
+	}
+	r, err := s.c.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	if r.MatchedCount > 0 {
+		job.Attempt = number + 1
+	}
+	return r.MatchedCount > 0, nil
+}
+
+// GetLastFireTimes returns fire time of the latest auto job for each task.
+func (s *jobStore) GetLastFireTimes(tasks []string) (map[string]time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.M{"task": bson.M{"$in": tasks}, "mode": 0}}},
+		{{"$group", bson.M{"_id": "$task", "fire": bson.M{"$max": "$fire_time"}}}},
+	}
+	cur, err := s.c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var items []struct {
+		Task string    `bson:"_id"`
+		Fire time.Time `bson:"fire"`
+	}
+	if err = cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]time.Time, len(items))
+	for _, item := range items {
+		m[item.Task] = item.Fire
+	}
+	return m, nil
+}
+
+func (s *jobStore) GetLastJobs(tasks []string) (map[string]*Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"task": bson.M{"$in": tasks}, "mode": 0, "parent": bson.M{"$exists": false}}}},
+		{{Key: "$sort", Value: bson.M{"_id": -1}}},
+		{{Key: "$group", Value: bson.M{"_id": "$task", "job": bson.M{"$first": "$$ROOT"}}}},
+	}
+	cur, err := s.c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var items []struct {
+		Task string `bson:"_id"`
+		Job  *Job   `bson:"job"`
+	}
+	if err = cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]*Job, len(items))
+	for _, item := range items {
+		m[item.Task] = item.Job
+	}
+	return m, nil
+}
+
+func (s *jobStore) FetchActive(task string, before primitive.ObjectID) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"task":            task,
+		"parent":          bson.M{"$exists": false},
+		"queued":          bson.M{"$ne": true},
+		"delayed":         bson.M{"$ne": true},
+		"dispatch.status": bson.M{"$in": bson.A{JobStatusUnknown, JobStatusSuccess}},
+		"execute.status":  JobStatusUnknown,
+	}
+	if !before.IsZero() {
+		filter["_id"] = bson.M{"$lt": before}
+	}
+	cur, err := s.c.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+// Skip marks job as skipped without dispatching it.
+func (s *jobStore) Skip(id primitive.ObjectID, error string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"dispatch.status": JobStatusSkipped,
+		"dispatch.error":  error,
+		"dispatch.time":   time.Now(),
+		"execute.status":  JobStatusSkipped,
+	}
+	_, err := s.c.UpdateByID(ctx, id, bson.M{"$set": update})
+	return err
+}
+
+// Queue marks job as waiting for running jobs of task to finish.
+func (s *jobStore) Queue(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.UpdateByID(ctx, id, bson.M{"$set": bson.M{"queued": true}})
+	return err
+}
+
+func (s *jobStore) FetchQueued(task string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.M{"_id": 1})
+	j := &Job{}
+	err := s.c.FindOne(ctx, bson.M{"task": task, "queued": true}, opts).Decode(j)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (s *jobStore) Dequeue(id primitive.ObjectID) (*Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{
+		"$set":   bson.M{"dispatch.due": time.Now()},
+		"$unset": bson.M{"queued": ""},
+	}
+	r := s.c.FindOneAndUpdate(ctx, bson.M{"_id": id, "queued": true}, update, opts)
+	j := &Job{}
+	if err := r.Decode(j); err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FetchQueuedTasks returns names of tasks which have queued jobs.
+func (s *jobStore) FetchQueuedTasks() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	values, err := s.c.Distinct(ctx, "task", bson.M{"queued": true})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]string, 0, len(values))
+	for _, v := range values {
+		if t, ok := v.(string); ok {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func (s *jobStore) FetchDue(now time.Time, limit int64) (jobs []*Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"delayed":   true,
+		"fire_time": bson.M{"$lte": now},
+	}
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"fire_time": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &jobs)
+	return
+}
+
+func (s *jobStore) NextDue() (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.M{"fire_time": 1}).SetProjection(bson.M{"fire_time": 1})
+	r := s.c.FindOne(ctx, bson.M{"delayed": true}, opts)
+	j := struct {
+		FireTime time.Time `bson:"fire_time"`
+	}{}
+	if err := r.Decode(&j); err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return j.FireTime, nil
+}
+
+func (s *jobStore) Claim(id primitive.ObjectID, scheduler string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"scheduler": scheduler, "dispatch.due": time.Now()},
+		"$unset": bson.M{"delayed": ""},
+	}
+	r, err := s.c.UpdateOne(ctx, bson.M{"_id": id, "delayed": true}, update)
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+func (s *jobStore) CancelDelayed(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"dispatch.status":  JobStatusCancelled,
+			"dispatch.error":   "cancelled before dispatching",
+			"execute.status":   JobStatusCancelled,
+			"execute.end_time": time.Now(),
+		},
+		"$unset": bson.M{"delayed": ""},
+	}
+	r, err := s.c.UpdateOne(ctx, bson.M{"_id": id, "delayed": true}, update)
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+func (s *jobStore) Delay(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"delayed": true}})
+	return err
+}
+
 func (s *jobStore) CreateIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
 		{
 			Keys:    bson.D{{"task", 1}},
 		},
+		{
+			Keys:    bson.D{{"parent", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"execute.deadline", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"execute.heartbeat", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"dispatch.due", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"queued", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{"delayed", 1}, {"fire_time", 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"delayed": true}),
+		},
 		{
 			Keys:    bson.D{{"fire_time", 1}},
 			Options: options.Index().SetExpireAfterSeconds(3600 * 24 * 7),
@@ -183,7 +748,7 @@ func (s *jobStore) Count(ctx context.Context) (int64, error) {
 
 func (s *jobStore) status(success bool) int32 {
 	if success {
-		return 1
+		return JobStatusSuccess
 	}
-	return 2
+	return JobStatusFailed
 }
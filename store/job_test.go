@@ -0,0 +1,343 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestJob(t *testing.T, s JobStore) *Job {
+	t.Helper()
+
+	job := &Job{Id: primitive.NewObjectID(), Task: "test", FireTime: Time(time.Now())}
+	if err := s.Create(job); err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestJobTimeout(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	overdue := newTestJob(t, s)
+	if err := s.ModifyDispatch(overdue.Id.Hex(), true, "", "", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	running := newTestJob(t, s)
+	if err := s.ModifyDispatch(running.Id.Hex(), true, "", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	finished := newTestJob(t, s)
+	if err := s.ModifyDispatch(finished.Id.Hex(), true, "", "", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ModifyExecute(finished.Id.Hex(), 0, JobStatusSuccess, "", time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := s.FetchOverdue(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Id != overdue.Id {
+		t.Fatalf("expected only overdue job, got %d jobs", len(jobs))
+	}
+
+	ok, err := s.Timeout(overdue.Id, "timeout")
+	if err != nil || !ok {
+		t.Fatalf("Timeout: %v, %v", ok, err)
+	}
+	// a job can only be timed out once
+	if ok, _ = s.Timeout(overdue.Id, "timeout"); ok {
+		t.Fatal("job was timed out twice")
+	}
+	// a finished job can't be timed out
+	if ok, _ = s.Timeout(finished.Id, "timeout"); ok {
+		t.Fatal("finished job was timed out")
+	}
+
+	job, err := s.Find(overdue.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Execute.Status != JobStatusTimeout || job.Execute.EndTime == nil {
+		t.Fatalf("unexpected execute state: %+v", job.Execute)
+	}
+	if jobs, _ = s.FetchOverdue(10); len(jobs) != 0 {
+		t.Fatalf("expected no overdue jobs, got %d", len(jobs))
+	}
+}
+
+func TestJobHeartbeat(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	job := newTestJob(t, s)
+	before := time.Now()
+	if jobs, err := s.FetchLost(before, 10); err != nil {
+		t.Fatal(err)
+	} else if len(jobs) != 0 {
+		t.Fatal("job without heartbeat should not be lost")
+	}
+
+	if err := s.Heartbeat(job.Id.Hex(), "runner-1"); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := s.FetchLost(before, 10); len(jobs) != 0 {
+		t.Fatal("job with fresh heartbeat should not be lost")
+	}
+	jobs, err := s.FetchLost(time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Execute.Runner != "runner-1" || jobs[0].Execute.Heartbeat == nil {
+		t.Fatalf("unexpected lost jobs: %+v", jobs)
+	}
+
+	// heartbeats of finished jobs are rejected
+	if _, err = s.ModifyExecute(job.Id.Hex(), 0, JobStatusSuccess, "", time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Heartbeat(job.Id.Hex(), "runner-1"); err == nil {
+		t.Fatal("expected error for heartbeat of finished job")
+	}
+	if jobs, _ = s.FetchLost(time.Now().Add(time.Minute), 10); len(jobs) != 0 {
+		t.Fatal("finished job should not be lost")
+	}
+}
+
+func TestJobModifyExecute(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	job := newTestJob(t, s)
+	if err := s.ModifyDispatch(job.Id.Hex(), true, "", "http://127.0.0.1:8002", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	// results of other attempts are rejected
+	if j, err := s.ModifyExecute(job.Id.Hex(), 2, JobStatusSuccess, "", time.Now(), time.Now()); err != nil || j != nil {
+		t.Fatalf("result of another attempt should be ignored, got %v, %v", j, err)
+	}
+	// jobs without attempt field are treated as first attempt
+	job, err := s.ModifyExecute(job.Id.Hex(), 1, JobStatusCancelled, "job was cancelled", time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Dispatch.Address != "http://127.0.0.1:8002" {
+		t.Fatalf("unexpected dispatch address: %s", job.Dispatch.Address)
+	}
+	if job.Execute.Status != JobStatusCancelled || job.Execute.Error != "job was cancelled" {
+		t.Fatalf("unexpected execute state: %+v", job.Execute)
+	}
+	// settled jobs can't be modified again
+	if j, err := s.ModifyExecute(job.Id.Hex(), 1, JobStatusSuccess, "", time.Now(), time.Now()); err != nil || j != nil {
+		t.Fatalf("result of settled job should be ignored, got %v, %v", j, err)
+	}
+}
+
+func TestJobSettleBatch(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	parent := newTestJob(t, s)
+	if err := s.SetBatches(parent.Id.Hex(), 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, success := range []bool{true, false, true} {
+		child := &Job{Id: primitive.NewObjectID(), Task: "test", Parent: parent.Id.Hex(), FireTime: parent.FireTime}
+		if err := s.Create(child); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if parent, err = s.SettleBatch(parent.Id.Hex(), success); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if b := parent.Batches; b == nil || b.Total != 3 || b.Success != 2 || b.Failed != 1 {
+		t.Fatalf("unexpected batches: %+v", b)
+	}
+	children, err := s.FetchChildren(parent.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 3 {
+		t.Fatalf("expected 3 children, got %d", len(children))
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	running := newTestJob(t, s)
+	skipped := newTestJob(t, s)
+	queued := newTestJob(t, s)
+
+	if err := s.Skip(skipped.Id, "skipped"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Queue(queued.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	// skipped and queued jobs are not active
+	jobs, err := s.FetchActive("test", primitive.NilObjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Id != running.Id {
+		t.Fatalf("expected only running job to be active, got %d jobs", len(jobs))
+	}
+	// only jobs created before the specified one are counted
+	if jobs, _ = s.FetchActive("test", running.Id); len(jobs) != 0 {
+		t.Fatalf("expected no active jobs before the first job, got %d", len(jobs))
+	}
+
+	tasks, err := s.FetchQueuedTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0] != "test" {
+		t.Fatalf("unexpected queued tasks: %v", tasks)
+	}
+
+	j, err := s.FetchQueued("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j == nil || j.Id != queued.Id {
+		t.Fatalf("unexpected queued job: %+v", j)
+	}
+	if j, err = s.Dequeue(queued.Id); err != nil {
+		t.Fatal(err)
+	}
+	if j == nil || j.Id != queued.Id || j.Dispatch.Due == nil {
+		t.Fatalf("unexpected dequeued job: %+v", j)
+	}
+	// job can only be dequeued once
+	if j, err = s.Dequeue(queued.Id); err != nil || j != nil {
+		t.Fatalf("expected job not to be queued, got %v, %v", j, err)
+	}
+	if j, err = s.FetchQueued("test"); err != nil || j != nil {
+		t.Fatalf("expected no queued job, got %v, %v", j, err)
+	}
+	if jobs, _ = s.FetchActive("test", primitive.NilObjectID); len(jobs) != 2 {
+		t.Fatalf("expected dequeued job to be active, got %d active jobs", len(jobs))
+	}
+}
+
+func TestJobStalled(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	due := Time(time.Now().Add(-time.Hour))
+	stalled := &Job{Id: primitive.NewObjectID(), Task: "test", FireTime: due, Dispatch: JobDispatch{Due: &due}}
+	if err := s.Create(stalled); err != nil {
+		t.Fatal(err)
+	}
+	dispatched := &Job{Id: primitive.NewObjectID(), Task: "test", FireTime: due, Dispatch: JobDispatch{Due: &due}}
+	if err := s.Create(dispatched); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ModifyDispatch(dispatched.Id.Hex(), true, "", "", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := s.FetchStalled(time.Now().Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Id != stalled.Id {
+		t.Fatalf("expected only stalled job, got %d jobs", len(jobs))
+	}
+	if jobs, _ = s.FetchStalled(time.Now().Add(-2*time.Hour), 10); len(jobs) != 0 {
+		t.Fatalf("expected no jobs stalled before due, got %d", len(jobs))
+	}
+
+	ok, err := s.Abandon(stalled.Id, "stalled")
+	if err != nil || !ok {
+		t.Fatalf("Abandon: %v, %v", ok, err)
+	}
+	if ok, _ = s.Abandon(dispatched.Id, "stalled"); ok {
+		t.Fatal("dispatched job was abandoned")
+	}
+	if jobs, _ = s.FetchActive("test", primitive.NilObjectID); len(jobs) != 1 || jobs[0].Id != dispatched.Id {
+		t.Fatalf("expected abandoned job to be inactive, got %d active jobs", len(jobs))
+	}
+
+	// next attempt of job is expected to be dispatched at the specified time
+	next := time.Now().Add(time.Minute)
+	if ok, err = s.Reattempt(jobs[0], next); err != nil || !ok {
+		t.Fatalf("Reattempt: %v, %v", ok, err)
+	}
+	j, err := s.Find(dispatched.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Dispatch.Due == nil || !time.Time(*j.Dispatch.Due).Equal(next.Truncate(time.Millisecond)) {
+		t.Fatalf("unexpected due of next attempt: %v", j.Dispatch.Due)
+	}
+}
+
+func TestJobDelayed(t *testing.T) {
+	s := NewJobStore(testDB(t))
+
+	now := time.Now()
+	past := Time(now.Add(-time.Hour))
+	due := &Job{Id: primitive.NewObjectID(), Task: "test", FireTime: Time(now.Add(-time.Minute)), Delayed: true, Dispatch: JobDispatch{Due: &past}}
+	future := &Job{Id: primitive.NewObjectID(), Task: "test", FireTime: Time(now.Add(time.Hour)), Delayed: true, Dispatch: JobDispatch{Due: &past}}
+	for _, j := range []*Job{due, future} {
+		if err := s.Create(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next, err := s.NextDue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Equal(time.Time(due.FireTime).Truncate(time.Millisecond)) {
+		t.Fatalf("got next due %s, want %s", next, time.Time(due.FireTime))
+	}
+	// delayed jobs are not dispatched yet, but they are not stalled
+	if jobs, _ := s.FetchStalled(now, 10); len(jobs) != 0 {
+		t.Fatalf("delayed jobs should not be stalled, got %d", len(jobs))
+	}
+
+	jobs, err := s.FetchDue(now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Id != due.Id {
+		t.Fatalf("expected only due job, got %d jobs", len(jobs))
+	}
+
+	ok, err := s.Claim(due.Id, "node-1")
+	if err != nil || !ok {
+		t.Fatalf("Claim: %v, %v", ok, err)
+	}
+	// a delayed job can only be claimed by one scheduler
+	if ok, _ = s.Claim(due.Id, "node-2"); ok {
+		t.Fatal("job was claimed twice")
+	}
+	j, err := s.Find(due.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Delayed || j.Scheduler != "node-1" || j.Dispatch.Due == nil || time.Time(*j.Dispatch.Due).Before(now.Truncate(time.Millisecond)) {
+		t.Fatalf("unexpected claimed job: %+v", j)
+	}
+
+	if ok, err = s.CancelDelayed(future.Id); err != nil || !ok {
+		t.Fatalf("CancelDelayed: %v, %v", ok, err)
+	}
+	if ok, _ = s.CancelDelayed(due.Id); ok {
+		t.Fatal("claimed job was cancelled")
+	}
+	if j, err = s.Find(future.Id.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if j.Delayed || j.Execute.Status != JobStatusCancelled {
+		t.Fatalf("unexpected cancelled job: %+v", j)
+	}
+	if next, _ = s.NextDue(); !next.IsZero() {
+		t.Fatalf("expected no delayed jobs, got next due %s", next)
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Node is a scheduler instance registered in cluster, it is alive while heartbeat keeps updating.
+type Node struct {
+	Id        string `json:"id" bson:"_id"`
+	Host      string `json:"host" bson:"host"`
+	Pid       int    `json:"pid" bson:"pid"`
+	Version   string `json:"version" bson:"version"`
+	Lock      string `json:"lock" bson:"lock"`                               // lock backend, e.g. mongo, redis, null
+	Interval  int32  `json:"interval" bson:"interval"`                       // seconds between heartbeats
+	HeapSize  int    `json:"heap_size" bson:"heap_size"`                     // count of tasks scheduled by this node
+	LastTick  *Time  `json:"last_tick,omitempty" bson:"last_tick,omitempty"` // last time the heap was checked
+	LastFire  *Time  `json:"last_fire,omitempty" bson:"last_fire,omitempty"` // fire time of last job dispatched by heap
+	Queued    int    `json:"queued" bson:"queued"`                           // jobs waiting in dispatch queue
+	Busy      int    `json:"busy" bson:"busy"`                               // dispatch workers which are busy
+	Dropped   int64  `json:"dropped" bson:"dropped"`                         // jobs dropped because dispatch queue was full
+	Lag       int64  `json:"lag" bson:"lag"`                                 // milliseconds, max delay from fire time to dispatching in last heartbeat interval
+	StartTime Time   `json:"start_time" bson:"start_time"`
+	Heartbeat Time   `json:"heartbeat" bson:"heartbeat"`
+}
+
+// Stale reports whether node missed 3 heartbeats.
+func (n *Node) Stale(now time.Time) bool {
+	return now.Sub(time.Time(n.Heartbeat)) > 3*time.Duration(n.Interval)*time.Second
+}
+
+type NodeStore interface {
+	// Heartbeat registers node or refreshes its heartbeat.
+	Heartbeat(n *Node) error
+	// FetchAlive returns nodes whose heartbeat is after since, all nodes are returned if since is zero.
+	FetchAlive(since time.Time) ([]*Node, error)
+	Delete(id string) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type nodeStore struct {
+	c *mongo.Collection
+}
+
+func NewNodeStore(db *mongo.Database) NodeStore {
+	return &nodeStore{
+		c: db.Collection("node"),
+	}
+}
+
+func (s *nodeStore) Heartbeat(n *Node) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.ReplaceOne(ctx, bson.M{"_id": n.Id}, n, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *nodeStore) FetchAlive(since time.Time) (nodes []*Node, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	filter := bson.M{}
+	if !since.IsZero() {
+		filter["heartbeat"] = bson.M{"$gt": since}
+	}
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &nodes)
+	return
+}
+
+func (s *nodeStore) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *nodeStore) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			// remove nodes which are dead for a day
+			Keys:    bson.D{{Key: "heartbeat", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(3600 * 24),
+		},
+	}
+	_, err := s.c.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNodeHeartbeat(t *testing.T) {
+	s := NewNodeStore(testDB(t))
+
+	now := time.Now()
+	for id, heartbeat := range map[string]time.Time{"a": now, "b": now.Add(-time.Minute)} {
+		if err := s.Heartbeat(&Node{Id: id, StartTime: Time(now), Heartbeat: Time(heartbeat)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nodes, err := s.FetchAlive(now.Add(-30 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Id != "a" {
+		t.Fatalf("expected only node a to be alive, got %d nodes", len(nodes))
+	}
+
+	// heartbeat brings node back
+	if err = s.Heartbeat(&Node{Id: "b", StartTime: Time(now), Heartbeat: Time(now)}); err != nil {
+		t.Fatal(err)
+	}
+	if nodes, _ = s.FetchAlive(now.Add(-30 * time.Second)); len(nodes) != 2 || nodes[0].Id != "a" || nodes[1].Id != "b" {
+		t.Fatalf("expected nodes a and b to be alive, got %d nodes", len(nodes))
+	}
+
+	if err = s.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if nodes, _ = s.FetchAlive(now.Add(-30 * time.Second)); len(nodes) != 1 || nodes[0].Id != "b" {
+		t.Fatalf("expected only node b to be alive, got %d nodes", len(nodes))
+	}
+}
+
+func TestNodeStatus(t *testing.T) {
+	s := NewNodeStore(testDB(t))
+
+	now := time.Now()
+	tick := Time(now)
+	n := &Node{Id: "a", Version: "1.0", Lock: "mongo", Interval: 10, HeapSize: 3, LastTick: &tick, Heartbeat: Time(now.Add(-time.Hour))}
+	if err := s.Heartbeat(n); err != nil {
+		t.Fatal(err)
+	}
+
+	// dead nodes are returned too if since is zero
+	nodes, err := s.FetchAlive(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(nodes))
+	}
+	if got := nodes[0]; got.Lock != "mongo" || got.Interval != 10 || got.HeapSize != 3 || got.LastTick == nil || got.LastFire != nil {
+		t.Fatalf("unexpected node: %+v", got)
+	}
+	if !nodes[0].Stale(now) {
+		t.Fatal("node missed heartbeats should be stale")
+	}
+	if nodes[0].Stale(time.Time(nodes[0].Heartbeat).Add(20 * time.Second)) {
+		t.Fatal("node missed less than 3 heartbeats should not be stale")
+	}
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/cuigh/auxo/data"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Pull is a job waiting to be pulled by runners of a group. A delivered job is offered again after its visibility
+// timeout if it is not acked, so a job is delivered at least once even if runner crashed.
+type Pull struct {
+	Id         primitive.ObjectID `json:"id" bson:"_id"` // id of job
+	Group      string             `json:"group" bson:"group"`
+	Task       string             `json:"task" bson:"task"`
+	Handler    string             `json:"handler" bson:"handler"`
+	Args       data.Options       `json:"args,omitempty" bson:"args,omitempty"`
+	Mode       int32              `json:"mode" bson:"mode"`
+	Fire       Time               `json:"fire" bson:"fire"`
+	Attempt    int32              `json:"attempt,omitempty" bson:"attempt,omitempty"`
+	Visible    Time               `json:"visible" bson:"visible"`                     // job can't be received before it
+	Deliveries int32              `json:"deliveries" bson:"deliveries"`               // count of deliveries
+	Receipt    string             `json:"receipt,omitempty" bson:"receipt,omitempty"` // token of the latest delivery, required by ack
+	Runner     string             `json:"runner,omitempty" bson:"runner,omitempty"`   // instance of runner which received the latest delivery
+	CreateTime Time               `json:"create_time" bson:"create_time"`
+}
+
+type PullStore interface {
+	// Offer puts job into queue, a pending attempt of the same job is replaced.
+	Offer(p *Pull) error
+	// Receive takes a visible job of group for runner and hides it for visibility, handlers are not checked if empty.
+	// It returns nil if there is no visible job.
+	Receive(group string, handlers []string, runner string, visibility time.Duration) (*Pull, error)
+	// Ack removes a delivered job from queue, it returns false if receipt is stale, e.g. job was redelivered.
+	Ack(id primitive.ObjectID, receipt string) (bool, error)
+	// Extend hides a delivered job for visibility again while runner is still executing it.
+	Extend(id primitive.ObjectID, runner string, visibility time.Duration) error
+	// Withdraw removes job from queue if it is not being delivered.
+	Withdraw(id primitive.ObjectID) (bool, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type pullStore struct {
+	c *mongo.Collection
+}
+
+func NewPullStore(db *mongo.Database) PullStore {
+	return &pullStore{
+		c: db.Collection("pull"),
+	}
+}
+
+func (s *pullStore) Offer(p *Pull) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.ReplaceOne(ctx, bson.M{"_id": p.Id}, p, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *pullStore) Receive(group string, handlers []string, runner string, visibility time.Duration) (*Pull, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"group": group, "visible": bson.M{"$lte": now}}
+	if len(handlers) > 0 {
+		filter["handler"] = bson.M{"$in": handlers}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"visible": now.Add(visibility),
+			"receipt": primitive.NewObjectID().Hex(),
+			"runner":  runner,
+		},
+		"$inc": bson.M{"deliveries": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"visible": 1}).SetReturnDocument(options.After)
+
+	p := &Pull{}
+	err := s.c.FindOneAndUpdate(ctx, filter, update, opts).Decode(p)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *pullStore) Ack(id primitive.ObjectID, receipt string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.c.DeleteOne(ctx, bson.M{"_id": id, "receipt": receipt})
+	if err != nil {
+		return false, err
+	}
+	return r.DeletedCount > 0, nil
+}
+
+func (s *pullStore) Extend(id primitive.ObjectID, runner string, visibility time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "runner": runner}
+	update := bson.M{"$set": bson.M{"visible": time.Now().Add(visibility)}}
+	_, err := s.c.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (s *pullStore) Withdraw(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.c.DeleteOne(ctx, bson.M{"_id": id, "visible": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return r.DeletedCount > 0, nil
+}
+
+func (s *pullStore) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "group", Value: 1}, {Key: "visible", Value: 1}},
+		},
+	}
+	_, err := s.c.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestPull(t *testing.T, s PullStore, group, handler string) *Pull {
+	t.Helper()
+
+	now := Time(time.Now())
+	p := &Pull{Id: primitive.NewObjectID(), Group: group, Task: "test", Handler: handler, Fire: now, Visible: now, CreateTime: now}
+	if err := s.Offer(p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPullReceive(t *testing.T) {
+	s := NewPullStore(testDB(t))
+	a := newTestPull(t, s, "g1", "a")
+	newTestPull(t, s, "g2", "a")
+
+	// jobs of other handlers or groups are not received
+	if p, err := s.Receive("g1", []string{"b"}, "r1", time.Minute); err != nil || p != nil {
+		t.Fatalf("expected nothing for handler b, got %v, %v", p, err)
+	}
+
+	p, err := s.Receive("g1", []string{"a", "b"}, "r1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.Id != a.Id || p.Deliveries != 1 || p.Receipt == "" || p.Runner != "r1" {
+		t.Fatalf("unexpected delivery: %+v", p)
+	}
+
+	// a delivered job is invisible until visibility timeout
+	if q, _ := s.Receive("g1", nil, "r2", time.Minute); q != nil {
+		t.Fatal("delivered job should be invisible")
+	}
+	if ok, _ := s.Withdraw(a.Id); ok {
+		t.Fatal("delivered job should not be withdrawn")
+	}
+
+	ok, err := s.Ack(a.Id, p.Receipt)
+	if err != nil || !ok {
+		t.Fatalf("Ack: %v, %v", ok, err)
+	}
+	if ok, _ = s.Ack(a.Id, p.Receipt); ok {
+		t.Fatal("job was acked twice")
+	}
+}
+
+func TestPullRedeliver(t *testing.T) {
+	s := NewPullStore(testDB(t))
+	a := newTestPull(t, s, "g1", "a")
+
+	first, err := s.Receive("g1", nil, "r1", -time.Second)
+	if err != nil || first == nil {
+		t.Fatalf("Receive: %v, %v", first, err)
+	}
+	// visibility timed out, so job is delivered again
+	second, err := s.Receive("g1", nil, "r2", time.Minute)
+	if err != nil || second == nil {
+		t.Fatalf("Receive: %v, %v", second, err)
+	}
+	if second.Deliveries != 2 || second.Receipt == first.Receipt || second.Runner != "r2" {
+		t.Fatalf("unexpected redelivery: %+v", second)
+	}
+
+	// receipt of the first delivery is stale
+	if ok, _ := s.Ack(a.Id, first.Receipt); ok {
+		t.Fatal("job was acked with stale receipt")
+	}
+
+	// only runner of the latest delivery can extend it
+	if err = s.Extend(a.Id, "r1", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := s.Receive("g1", nil, "r3", time.Minute); p != nil {
+		t.Fatal("job should be invisible after extending by other runner")
+	}
+	if err = s.Extend(a.Id, "r2", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Withdraw(a.Id); err != nil || !ok {
+		t.Fatalf("Withdraw: %v, %v", ok, err)
+	}
+	if p, _ := s.Receive("g1", nil, "r3", time.Minute); p != nil {
+		t.Fatal("withdrawn job should not be received")
+	}
+}
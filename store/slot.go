@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Slot is a concurrency slot of task held by a running job. Slots of a task are numbered and each one is a document
+// with a unique id, so concurrency limit is enforced atomically across scheduler nodes.
+type Slot struct {
+	Id   string             `json:"id" bson:"_id"` // {task}:{index}, or {task}:{job} if it was taken without limit
+	Task string             `json:"task" bson:"task"`
+	Job  primitive.ObjectID `json:"job" bson:"job"`
+	Time Time               `json:"time" bson:"time"`
+}
+
+type SlotStore interface {
+	// Acquire takes a slot of task for job if fewer than max jobs are holding slots, max <= 0 means unlimited.
+	// It returns true if job is holding a slot already.
+	Acquire(task string, job primitive.ObjectID, max int32) (bool, error)
+	// Release frees the slot held by job, it does nothing if job doesn't hold a slot.
+	Release(task string, job primitive.ObjectID) error
+	// FetchAll returns all slots which are held by jobs.
+	FetchAll() ([]*Slot, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type slotStore struct {
+	c *mongo.Collection
+}
+
+func NewSlotStore(db *mongo.Database) SlotStore {
+	return &slotStore{
+		c: db.Collection("slot"),
+	}
+}
+
+func (s *slotStore) Acquire(task string, job primitive.ObjectID, max int32) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n, err := s.c.CountDocuments(ctx, bson.M{"task": task, "job": job})
+	if err != nil {
+		return false, err
+	} else if n > 0 {
+		return true, nil
+	}
+
+	slot := &Slot{Task: task, Job: job, Time: Time(time.Now())}
+	if max <= 0 {
+		slot.Id = task + ":" + job.Hex()
+		_, err = s.c.InsertOne(ctx, slot)
+		return err == nil, err
+	}
+	for i := int32(0); i < max; i++ {
+		slot.Id = task + ":" + strconv.Itoa(int(i))
+		if _, err = s.c.InsertOne(ctx, slot); err == nil {
+			return true, nil
+		} else if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (s *slotStore) Release(task string, job primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.DeleteMany(ctx, bson.M{"task": task, "job": job})
+	return err
+}
+
+func (s *slotStore) FetchAll() (slots []*Slot, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := s.c.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &slots)
+	return
+}
+
+func (s *slotStore) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "task", Value: 1}, {Key: "job", Value: 1}},
+		},
+	}
+	_, err := s.c.Indexes().CreateMany(ctx, indexes)
+	return err
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSlotAcquire(t *testing.T) {
+	s := NewSlotStore(testDB(t))
+	acquire := func(job primitive.ObjectID, max int32) bool {
+		t.Helper()
+		ok, err := s.Acquire("test", job, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	j1, j2, j3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	if !acquire(j1, 2) || !acquire(j2, 2) {
+		t.Fatal("slots should be acquired")
+	}
+	if acquire(j3, 2) {
+		t.Fatal("slots are all taken")
+	}
+	// acquiring is idempotent
+	if !acquire(j1, 2) {
+		t.Fatal("job is holding a slot already")
+	}
+	// unlimited
+	if !acquire(j3, 0) {
+		t.Fatal("slot should be acquired without limit")
+	}
+
+	slots, err := s.FetchAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 || slots[0].Task != "test" {
+		t.Fatalf("unexpected slots: %+v", slots)
+	}
+
+	for _, id := range []primitive.ObjectID{j1, j3} {
+		if err = s.Release("test", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !acquire(j3, 2) {
+		t.Fatal("slot should be acquired after released")
+	}
+	if err = s.Release("missing", j1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlotAcquireConcurrently(t *testing.T) {
+	s := NewSlotStore(testDB(t))
+
+	var (
+		wg    sync.WaitGroup
+		count int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.Acquire("test", primitive.NewObjectID(), 3)
+			if err != nil {
+				t.Error(err)
+			} else if ok {
+				atomic.AddInt32(&count, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count != 3 {
+		t.Fatalf("expected 3 slots to be acquired, got %d", count)
+	}
+}
@@ -67,4 +67,11 @@ func init() {
 	ioc.Put(NewJobStore, ioc.Name("store.job"))
 	ioc.Put(NewRoleStore, ioc.Name("store.role"))
 	ioc.Put(NewConfigStore, ioc.Name("store.config"))
+	ioc.Put(NewWorkflowStore, ioc.Name("store.workflow"))
+	ioc.Put(NewRunStore, ioc.Name("store.run"))
+	ioc.Put(NewCalendarStore, ioc.Name("store.calendar"))
+	ioc.Put(NewNodeStore, ioc.Name("store.node"))
+	ioc.Put(NewPullStore, ioc.Name("store.pull"))
+	ioc.Put(NewSlotStore, ioc.Name("store.slot"))
+	ioc.Put(NewFireStore, ioc.Name("store.fire"))
 }
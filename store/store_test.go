@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDB returns a temporary database on the MongoDB server specified by SKYNET_TEST_MONGO,
+// the test is skipped if the variable is not set.
+func testDB(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("SKYNET_TEST_MONGO")
+	if uri == "" {
+		t.Skip("SKYNET_TEST_MONGO is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := client.Database("skynet_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
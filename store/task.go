@@ -11,17 +11,95 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	TriggerCron     int32 = iota // fire by cron expressions in Triggers
+	TriggerInterval              // fire every Trigger.Interval seconds from Trigger.Anchor
+	TriggerDelay                 // fire Trigger.Interval seconds after the previous job ends
+	TriggerOnce                  // fire once at Trigger.At, then task is disabled
+)
+
+const (
+	MisfireSkip     int32 = iota // ignore missed fires
+	MisfireFireOnce              // fire once for all missed fires
+	MisfireFireAll               // fire every missed fire, up to Misfire.Limit
+)
+
+const (
+	RetryOnDispatch int32 = 1 << iota // retry when dispatching failed
+	RetryOnExecute                    // retry when runner reports failure
+)
+
+const (
+	BackoffFixed int32 = iota
+	BackoffExponential
+)
+
+const (
+	PauseActionPause  = "pause"
+	PauseActionResume = "resume"
+)
+
+// maxPauseRecords limits pause/resume records kept in task.
+const maxPauseRecords = 50
+
+const (
+	OverlapSkip    int32 = iota // skip new job
+	OverlapQueue                // dispatch new job after a running job is finished
+	OverlapReplace              // cancel running jobs and dispatch new job
+)
+
 type Task struct {
 	Name        string       `json:"name" bson:"_id" valid:"required"`
 	Runner      string       `json:"runner" bson:"runner" valid:"required"`
 	Handler     string       `json:"handler,omitempty" bson:"handler,omitempty"`
 	Args        data.Options `json:"args" bson:"args"`
-	Triggers    []string     `json:"triggers" bson:"triggers"`
+	Triggers    []string     `json:"triggers" bson:"triggers"`                       // cron expressions for TriggerCron
+	TimeZone    string       `json:"timezone,omitempty" bson:"timezone,omitempty"`   // IANA name, e.g. Asia/Shanghai, empty means local zone
+	Calendars   []string     `json:"calendars,omitempty" bson:"calendars,omitempty"` // fire times excluded by any calendar are skipped
 	Description string       `json:"desc,omitempty" bson:"desc,omitempty"`
 	Enabled     bool         `json:"enabled" bson:"enabled"`
+	Parallel    bool         `json:"parallel,omitempty" bson:"parallel,omitempty"` // split job into batches and dispatch them in parallel
 	Maintainers []string     `json:"maintainers" bson:"maintainers"`
 	Alerts      []string     `json:"alerts" bson:"alerts"`
 	ModifyTime  Time         `json:"modify_time" bson:"modify_time"`
+	Trigger     struct {
+		Type     int32 `json:"type,omitempty" bson:"type,omitempty"`         // 0-Cron, 1-Interval, 2-Delay, 3-Once
+		Interval int32 `json:"interval,omitempty" bson:"interval,omitempty"` // seconds, for Interval and Delay
+		Anchor   *Time `json:"anchor,omitempty" bson:"anchor,omitempty"`     // start point of Interval, unix epoch if absent
+		At       *Time `json:"at,omitempty" bson:"at,omitempty"`             // fire time of Once
+		Jitter   int32 `json:"jitter,omitempty" bson:"jitter,omitempty"`     // seconds, dispatching of each fire is delayed randomly within it
+	} `json:"trigger" bson:"trigger"`
+	Misfire struct {
+		Policy int32 `json:"policy" bson:"policy"`                   // 0-Skip, 1-FireOnce, 2-FireAll
+		Limit  int32 `json:"limit,omitempty" bson:"limit,omitempty"` // max fires for FireAll, 0 means unlimited
+	} `json:"misfire" bson:"misfire"`
+	Retry struct {
+		Attempts int32 `json:"attempts,omitempty" bson:"attempts,omitempty"` // max attempts, 0 or 1 means no retry
+		On       int32 `json:"on,omitempty" bson:"on,omitempty"`             // 1-Dispatch failure, 2-Execute failure, 3-Both
+		Backoff  int32 `json:"backoff,omitempty" bson:"backoff,omitempty"`   // 0-Fixed, 1-Exponential
+		Interval int32 `json:"interval,omitempty" bson:"interval,omitempty"` // seconds, base interval for exponential backoff
+	} `json:"retry" bson:"retry"`
+	Timeout struct {
+		Duration int32 `json:"duration,omitempty" bson:"duration,omitempty"` // seconds, 0 means no timeout
+		Cancel   bool  `json:"cancel,omitempty" bson:"cancel,omitempty"`     // ask runner to cancel job when timed out
+		Dispatch int32 `json:"dispatch,omitempty" bson:"dispatch,omitempty"` // seconds, max time to wait for runner to accept job, 0 means default of runner
+	} `json:"timeout" bson:"timeout"`
+	Pause       *PauseRecord   `json:"pause,omitempty" bson:"pause,omitempty"`   // nil if task is not paused
+	Pauses      []*PauseRecord `json:"pauses,omitempty" bson:"pauses,omitempty"` // recent pause/resume records
+	Concurrency struct {
+		Max     int32 `json:"max,omitempty" bson:"max,omitempty"`         // max running jobs, 0 means unlimited
+		Overlap int32 `json:"overlap,omitempty" bson:"overlap,omitempty"` // 0-Skip, 1-Queue, 2-Replace
+	} `json:"concurrency" bson:"concurrency"`
+}
+
+// PauseRecord records who paused or resumed a task and why.
+type PauseRecord struct {
+	Action   string `json:"action" bson:"action"`                 // pause or resume
+	User     string `json:"user,omitempty" bson:"user,omitempty"` // id of operator, empty means system
+	UserName string `json:"user_name,omitempty" bson:"user_name,omitempty"`
+	Reason   string `json:"reason,omitempty" bson:"reason,omitempty"`
+	Time     Time   `json:"time" bson:"time"`
+	Until    *Time  `json:"until,omitempty" bson:"until,omitempty"` // task is resumed automatically after it
 }
 
 type TaskStore interface {
@@ -32,6 +110,18 @@ type TaskStore interface {
 	Search(name, runner string, pageIndex, pageSize int64) (tasks []*Task, total int64, err error)
 	GetState() (modify time.Time, count int64, err error)
 	FetchAll(enabled bool) ([]*Task, error)
+	// Disable disables task, it is used to stop one-shot tasks after firing.
+	Disable(name string) error
+	Pause(name string, r *PauseRecord) error
+	Resume(name string, r *PauseRecord) error
+	// ResumeExpired resumes paused tasks whose pause is expired before now.
+	ResumeExpired(now time.Time) (int64, error)
+	// CountByCalendar returns count of tasks which reference calendar.
+	CountByCalendar(name string) (int64, error)
+	// Watch calls fn with name and latest content of task whenever a task is created, modified or deleted(t is nil),
+	// ready is called after the change stream is opened. It blocks until ctx is done or the stream fails,
+	// and fails immediately if change streams are unsupported, e.g. MongoDB is not a replica set.
+	Watch(ctx context.Context, ready func(), fn func(name string, t *Task)) error
 	Count(ctx context.Context) (int64, error)
 }
 
@@ -73,6 +163,7 @@ func (s *taskStore) Create(t *Task) error {
 	defer cancel()
 
 	t.ModifyTime = Time(time.Now())
+	t.Pause, t.Pauses = nil, nil
 	_, err := s.c.InsertOne(ctx, t)
 	if mongo.IsDuplicateKeyError(err) {
 		return errors.Format("任务名 %s 已经存在", t.Name)
@@ -85,6 +176,8 @@ func (s *taskStore) Modify(t *Task) error {
 	defer cancel()
 
 	t.ModifyTime = Time(time.Now())
+	// pause state can only be changed by Pause/Resume
+	t.Pause, t.Pauses = nil, nil
 	r, err := s.c.UpdateByID(ctx, t.Name, bson.M{"$set": t})
 	if err != nil {
 		return err
@@ -169,6 +262,108 @@ func (s *taskStore) FetchAll(enabled bool) ([]*Task, error) {
 	return tasks, nil
 }
 
+func (s *taskStore) Disable(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"enabled": false, "modify_time": time.Now()}}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": name, "enabled": true}, update)
+	return err
+}
+
+// Pause marks task as paused, modify time is updated so that schedulers reload it.
+func (s *taskStore) Pause(name string, r *PauseRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r.Action, r.Time = PauseActionPause, Time(time.Now())
+	update := bson.M{
+		"$set":  bson.M{"pause": r, "modify_time": r.Time},
+		"$push": bson.M{"pauses": bson.M{"$each": bson.A{r}, "$slice": -maxPauseRecords}},
+	}
+	result, err := s.c.UpdateByID(ctx, name, update)
+	if err != nil {
+		return err
+	} else if result.MatchedCount == 0 {
+		return errors.Format("can't find task '%s'", name)
+	}
+	return nil
+}
+
+func (s *taskStore) Resume(name string, r *PauseRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r.Action, r.Time = PauseActionResume, Time(time.Now())
+	filter := bson.M{"_id": name, "pause": bson.M{"$exists": true}}
+	update := bson.M{
+		"$set":   bson.M{"modify_time": r.Time},
+		"$unset": bson.M{"pause": ""},
+		"$push":  bson.M{"pauses": bson.M{"$each": bson.A{r}, "$slice": -maxPauseRecords}},
+	}
+	result, err := s.c.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	} else if result.MatchedCount == 0 {
+		return errors.Format("task '%s' is not paused", name)
+	}
+	return nil
+}
+
+func (s *taskStore) ResumeExpired(now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := &PauseRecord{Action: PauseActionResume, Reason: "pause expired", Time: Time(now)}
+	update := bson.M{
+		"$set":   bson.M{"modify_time": r.Time},
+		"$unset": bson.M{"pause": ""},
+		"$push":  bson.M{"pauses": bson.M{"$each": bson.A{r}, "$slice": -maxPauseRecords}},
+	}
+	result, err := s.c.UpdateMany(ctx, bson.M{"pause.until": bson.M{"$lte": now}}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *taskStore) CountByCalendar(name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.c.CountDocuments(ctx, bson.M{"calendars": name})
+}
+
+func (s *taskStore) Watch(ctx context.Context, ready func(), fn func(name string, t *Task)) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	cs, err := s.c.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+
+	ready()
+	for cs.Next(ctx) {
+		e := struct {
+			DocumentKey struct {
+				Name string `bson:"_id"`
+			} `bson:"documentKey"`
+			FullDocument *Task `bson:"fullDocument"`
+		}{}
+		if err = cs.Decode(&e); err != nil {
+			return err
+		}
+		fn(e.DocumentKey.Name, e.FullDocument)
+	}
+	if err = cs.Err(); err == nil {
+		err = errors.New("change stream was closed")
+	}
+	return err
+}
+
 func (s *taskStore) Count(ctx context.Context) (int64, error) {
 	filter := bson.M{}
 	return s.c.CountDocuments(ctx, filter)
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestTask(t *testing.T, s TaskStore, name string) *Task {
+	t.Helper()
+
+	task := &Task{Name: name, Runner: "test", Triggers: []string{"0 * * * * *"}, Enabled: true}
+	if err := s.Create(task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestTaskPause(t *testing.T) {
+	s := NewTaskStore(testDB(t))
+	task := newTestTask(t, s, "test")
+
+	if err := s.Resume(task.Name, &PauseRecord{User: "1"}); err == nil {
+		t.Fatal("expected error for resuming a task which is not paused")
+	}
+	if err := s.Pause(task.Name, &PauseRecord{User: "1", Reason: "maintenance"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause("missing", &PauseRecord{}); err == nil {
+		t.Fatal("expected error for pausing a missing task")
+	}
+
+	paused, err := s.Find(task.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paused.Pause == nil || paused.Pause.Action != PauseActionPause || paused.Pause.Reason != "maintenance" {
+		t.Fatalf("unexpected pause: %+v", paused.Pause)
+	}
+	if time.Time(paused.ModifyTime).Before(time.Time(task.ModifyTime)) {
+		t.Fatal("modify time should be updated when task is paused")
+	}
+
+	if err = s.Resume(task.Name, &PauseRecord{User: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := s.Find(task.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Pause != nil {
+		t.Fatal("task should not be paused after resuming")
+	}
+	if len(resumed.Pauses) != 2 || resumed.Pauses[0].Action != PauseActionPause || resumed.Pauses[1].Action != PauseActionResume {
+		t.Fatalf("unexpected pause records: %+v", resumed.Pauses)
+	}
+}
+
+func TestTaskResumeExpired(t *testing.T) {
+	s := NewTaskStore(testDB(t))
+	expired := newTestTask(t, s, "expired")
+	later := newTestTask(t, s, "later")
+	forever := newTestTask(t, s, "forever")
+
+	now := time.Now()
+	past, future := Time(now.Add(-time.Minute)), Time(now.Add(time.Hour))
+	if err := s.Pause(expired.Name, &PauseRecord{Until: &past}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause(later.Name, &PauseRecord{Until: &future}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause(forever.Name, &PauseRecord{}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.ResumeExpired(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 task to be resumed, got %d", n)
+	}
+
+	for name, paused := range map[string]bool{expired.Name: false, later.Name: true, forever.Name: true} {
+		task, err := s.Find(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (task.Pause != nil) != paused {
+			t.Fatalf("task '%s': expected paused=%v", name, paused)
+		}
+	}
+}
+
+func TestTaskWatch(t *testing.T) {
+	s := NewTaskStore(testDB(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type change struct {
+		name string
+		task *Task
+	}
+	ready, changes, errs := make(chan struct{}), make(chan change, 10), make(chan error, 1)
+	go func() {
+		errs <- s.Watch(ctx, func() { close(ready) }, func(name string, t *Task) {
+			changes <- change{name, t}
+		})
+	}()
+
+	select {
+	case <-ready:
+	case err := <-errs:
+		t.Skipf("change streams are unsupported: %s", err)
+	}
+
+	next := func() change {
+		select {
+		case c := <-changes:
+			return c
+		case <-ctx.Done():
+			t.Fatal("change is not received")
+			return change{}
+		}
+	}
+
+	newTestTask(t, s, "test")
+	if c := next(); c.name != "test" || c.task == nil || c.task.Runner != "test" {
+		t.Fatalf("unexpected change of creating: %+v", c)
+	}
+	if err := s.Delete("test"); err != nil {
+		t.Fatal(err)
+	}
+	if c := next(); c.name != "test" || c.task != nil {
+		t.Fatalf("unexpected change of deleting: %+v", c)
+	}
+
+	cancel()
+	<-errs
+}
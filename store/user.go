@@ -205,7 +205,7 @@ func (s *userStore) Modify(u *User) error {
 	if err != nil {
 		return err
 	} else if r.MatchedCount == 0 {
-		return errors.Format("can't find user '%d'", u.Id)
+		return errors.Format("can't find user '%s'", u.Id)
 	}
 	return nil
 }
@@ -225,7 +225,7 @@ func (s *userStore) ModifyProfile(u *User) error {
 	if err != nil {
 		return err
 	} else if r.MatchedCount == 0 {
-		return errors.Format("can't find user '%d'", u.Id)
+		return errors.Format("can't find user '%s'", u.Id)
 	}
 	return nil
 }
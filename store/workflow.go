@@ -0,0 +1,342 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/cuigh/auxo/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	NodeFailureStop     int32 = iota // stop dispatching remaining nodes
+	NodeFailureContinue              // treat failed node as finished
+	NodeFailureRetry                 // dispatch node again, then stop if it still fails
+)
+
+const (
+	NodeStatusPending int32 = iota
+	NodeStatusRunning
+	NodeStatusSuccess
+	NodeStatusFailed
+	NodeStatusSkipped
+)
+
+const (
+	RunStatusRunning int32 = iota
+	RunStatusSuccess
+	RunStatusFailed
+)
+
+// Workflow is a DAG of tasks, runs of it are triggered by tasks whose runner is `workflow://{name}` or manually.
+type Workflow struct {
+	Name        string          `json:"name" bson:"_id" valid:"required"`
+	Description string          `json:"desc,omitempty" bson:"desc,omitempty"`
+	Nodes       []*WorkflowNode `json:"nodes" bson:"nodes"`
+	Maintainers []string        `json:"maintainers" bson:"maintainers"`
+	ModifyTime  Time            `json:"modify_time" bson:"modify_time"`
+}
+
+type WorkflowNode struct {
+	Id        string   `json:"id" bson:"id"` // unique in workflow
+	Task      string   `json:"task" bson:"task"`
+	Depends   []string `json:"depends,omitempty" bson:"depends,omitempty"` // id of upstream nodes
+	OnFailure int32    `json:"on_failure" bson:"on_failure"`               // 0-Stop, 1-Continue, 2-Retry
+	Retries   int32    `json:"retries,omitempty" bson:"retries,omitempty"` // max retries for Retry policy
+}
+
+// WorkflowRun is an execution of workflow, definition of nodes is copied so that modifying workflow doesn't affect it.
+type WorkflowRun struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id"`
+	Workflow  string             `json:"workflow" bson:"workflow"`
+	Job       string             `json:"job,omitempty" bson:"job,omitempty"` // id of the job which triggered this run
+	Status    int32              `json:"status" bson:"status"`               // 0-Running, 1-Success, 2-Failed
+	Stopped   bool               `json:"stopped,omitempty" bson:"stopped,omitempty"`
+	Nodes     []*RunNode         `json:"nodes" bson:"nodes"`
+	StartTime Time               `json:"start_time" bson:"start_time"`
+	EndTime   *Time              `json:"end_time,omitempty" bson:"end_time,omitempty"`
+}
+
+type RunNode struct {
+	WorkflowNode `bson:",inline"`
+	Status       int32  `json:"status" bson:"status"` // 0-Pending, 1-Running, 2-Success, 3-Failed, 4-Skipped
+	Job          string `json:"job,omitempty" bson:"job,omitempty"`
+	Attempts     int32  `json:"attempts,omitempty" bson:"attempts,omitempty"`
+}
+
+type WorkflowStore interface {
+	Find(name string) (*Workflow, error)
+	Delete(name string) error
+	Create(w *Workflow) error
+	Modify(w *Workflow) error
+	Search(name string, pageIndex, pageSize int64) (workflows []*Workflow, total int64, err error)
+}
+
+type workflowStore struct {
+	c *mongo.Collection
+}
+
+func NewWorkflowStore(db *mongo.Database) WorkflowStore {
+	return &workflowStore{
+		c: db.Collection("workflow"),
+	}
+}
+
+func (s *workflowStore) Find(name string) (*Workflow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := s.c.FindOne(ctx, bson.M{"_id": name})
+	w := &Workflow{}
+	if err := r.Decode(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *workflowStore) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.c.DeleteOne(ctx, bson.M{"_id": name})
+	if err == nil && r.DeletedCount == 0 {
+		return errors.Format("can't find workflow '%s'", name)
+	}
+	return err
+}
+
+func (s *workflowStore) Create(w *Workflow) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w.ModifyTime = Time(time.Now())
+	_, err := s.c.InsertOne(ctx, w)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.Format("工作流 %s 已经存在", w.Name)
+	}
+	return err
+}
+
+func (s *workflowStore) Modify(w *Workflow) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w.ModifyTime = Time(time.Now())
+	r, err := s.c.UpdateByID(ctx, w.Name, bson.M{"$set": w})
+	if err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return errors.Format("can't find workflow '%s'", w.Name)
+	}
+	return nil
+}
+
+func (s *workflowStore) Search(name string, pageIndex, pageSize int64) (workflows []*Workflow, total int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if name != "" {
+		filter["_id"] = name
+	}
+
+	// fetch total count
+	total, err = s.c.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSkip(pageSize * (pageIndex - 1)).SetLimit(pageSize).SetSort(bson.M{"_id": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &workflows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return workflows, total, nil
+}
+
+type RunStore interface {
+	Find(id string) (*WorkflowRun, error)
+	Search(workflow string, pageIndex, pageSize int64) (runs []*WorkflowRun, total int64, err error)
+	Create(run *WorkflowRun) error
+	// StartNode marks a pending node as running, it returns false if the node was already started by others.
+	StartNode(id primitive.ObjectID, node string) (bool, error)
+	// SetNodeJob records job of a running node.
+	SetNodeJob(id primitive.ObjectID, node, job string) error
+	// FinishNode sets status of a running node and returns the modified run.
+	FinishNode(id primitive.ObjectID, node string, status int32) (*WorkflowRun, error)
+	// RetryNode increases attempts of a running node.
+	RetryNode(id primitive.ObjectID, node string) error
+	// Stop marks a running run as stopped and skips all pending nodes, it returns the modified run,
+	// or nil if the run is already finished.
+	Stop(id primitive.ObjectID) (*WorkflowRun, error)
+	// Finish sets final status of run, it returns false if the run was already finished.
+	Finish(id primitive.ObjectID, status int32) (bool, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type runStore struct {
+	c *mongo.Collection
+}
+
+func NewRunStore(db *mongo.Database) RunStore {
+	return &runStore{
+		c: db.Collection("workflow_run"),
+	}
+}
+
+func (s *runStore) Find(id string) (*WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	r := s.c.FindOne(ctx, bson.M{"_id": oid})
+	run := &WorkflowRun{}
+	if err = r.Decode(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *runStore) Search(workflow string, pageIndex, pageSize int64) (runs []*WorkflowRun, total int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if workflow != "" {
+		filter["workflow"] = workflow
+	}
+
+	// fetch total count
+	total, err = s.c.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSkip(pageSize * (pageIndex - 1)).SetLimit(pageSize).SetSort(bson.M{"_id": -1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &runs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+func (s *runStore) Create(run *WorkflowRun) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.InsertOne(ctx, run)
+	return err
+}
+
+func (s *runStore) StartNode(id primitive.ObjectID, node string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":     id,
+		"stopped": bson.M{"$ne": true},
+		"nodes":   bson.M{"$elemMatch": bson.M{"id": node, "status": NodeStatusPending}},
+	}
+	update := bson.M{"$set": bson.M{"nodes.$.status": NodeStatusRunning, "nodes.$.attempts": 1}}
+	r, err := s.c.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+func (s *runStore) SetNodeJob(id primitive.ObjectID, node, job string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id, "nodes.id": node}, bson.M{"$set": bson.M{"nodes.$.job": job}})
+	return err
+}
+
+func (s *runStore) FinishNode(id primitive.ObjectID, node string, status int32) (*WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":   id,
+		"nodes": bson.M{"$elemMatch": bson.M{"id": node, "status": NodeStatusRunning}},
+	}
+	update := bson.M{"$set": bson.M{"nodes.$.status": status}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	run := &WorkflowRun{}
+	if err := s.c.FindOneAndUpdate(ctx, filter, update, opts).Decode(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *runStore) RetryNode(id primitive.ObjectID, node string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id, "nodes.id": node}, bson.M{"$inc": bson.M{"nodes.$.attempts": 1}})
+	return err
+}
+
+func (s *runStore) Stop(id primitive.ObjectID) (*WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"stopped": true, "nodes.$[n].status": NodeStatusSkipped}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"n.status": NodeStatusPending}},
+	})
+	run := &WorkflowRun{}
+	err := s.c.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": RunStatusRunning}, update, opts).Decode(run)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *runStore) Finish(id primitive.ObjectID, status int32) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": RunStatusRunning}
+	update := bson.M{"$set": bson.M{"status": status, "end_time": time.Now()}}
+	r, err := s.c.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return r.ModifiedCount > 0, nil
+}
+
+func (s *runStore) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{"workflow", 1}},
+		},
+		{
+			Keys:    bson.D{{"start_time", 1}},
+			Options: options.Index().SetExpireAfterSeconds(3600 * 24 * 7),
+		},
+	}
+	_, err := s.c.Indexes().CreateMany(ctx, indexes)
+	return err
+}
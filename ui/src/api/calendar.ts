@@ -0,0 +1,50 @@
+import ajax, { Result } from './ajax'
+
+export interface CalendarRange {
+    start: number;
+    end: number;
+    reason?: string;
+}
+
+export interface Calendar {
+    name: string;
+    desc?: string;
+    timezone?: string;
+    weekdays?: number[];
+    excluded?: string[];
+    included?: string[];
+    blackouts?: CalendarRange[];
+    maintainers?: string[];
+    modify_time?: number;
+}
+
+export interface SearchArgs {
+    name?: string;
+    page_index: number;
+    page_size: number;
+}
+
+export interface SearchResult {
+    items: Calendar[];
+    total: number;
+}
+
+export class CalendarApi {
+    find(name: string) {
+        return ajax.get<Calendar>('/calendar/find', { name })
+    }
+
+    search(args: SearchArgs) {
+        return ajax.get<SearchResult>('/calendar/search', args)
+    }
+
+    save(calendar: Calendar) {
+        return ajax.post<Result<Object>>('/calendar/save', calendar)
+    }
+
+    delete(name: string) {
+        return ajax.post<Result<Object>>('/calendar/delete', { name })
+    }
+}
+
+export default new CalendarApi
@@ -1,5 +1,28 @@
 import ajax, { Result } from './ajax'
 
+export interface Dispatch {
+    status: number;
+    error?: string;
+    time: number;
+    address?: string;
+}
+
+export interface Execute {
+    status: number;
+    error?: string;
+    end_time: number;
+    start_time: number;
+    deadline?: number;
+    heartbeat?: number;
+    runner?: string;
+}
+
+export interface Attempt {
+    number: number;
+    dispatch: Dispatch;
+    execute: Execute;
+}
+
 export interface Job {
     id: string;
     task: string;
@@ -11,17 +34,21 @@ export interface Job {
         name: string;
         value: string;
     }[];
-    dispatch: {
-        status: number;
-        error?: string;
-        time: number;
-    },
-    execute: {
-        status: number;
-        error?: string;
-        end_time: number;
-        start_time: number;
-    },
+    attempt?: number;
+    parent?: string;
+    batch?: string;
+    run?: string;
+    node?: string;
+    queued?: boolean;
+    delayed?: boolean;
+    batches?: {
+        total: number;
+        success: number;
+        failed: number;
+    };
+    attempts?: Attempt[];
+    dispatch: Dispatch;
+    execute: Execute;
 }
 
 export interface SearchArgs {
@@ -59,6 +86,14 @@ export class JobApi {
     retry(id: string) {
         return ajax.post<Result<Object>>('/job/retry', { id })
     }
+
+    children(id: string) {
+        return ajax.get<Job[]>('/job/children', { id })
+    }
+
+    cancel(id: string) {
+        return ajax.post<Result<Object>>('/job/cancel', { id })
+    }
 }
 
 export default new JobApi
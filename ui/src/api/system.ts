@@ -20,6 +20,25 @@ export interface Summary {
     userCount: number;
 }
 
+export interface Node {
+    id: string;
+    host: string;
+    pid: number;
+    version: string;
+    lock: string;
+    interval: number;
+    heap_size: number;
+    last_tick?: number;
+    last_fire?: number;
+    queued: number;
+    busy: number;
+    dropped: number;
+    lag: number;
+    start_time: number;
+    heartbeat: number;
+    stale: boolean;
+}
+
 export class SystemApi {
     checkState() {
         return ajax.get<State>('/system/check-state')
@@ -36,6 +55,10 @@ export class SystemApi {
     summarize() {
         return ajax.get<Summary>('/system/summarize')
     }
+
+    nodes() {
+        return ajax.get<Node[]>('/system/nodes')
+    }
 }
 
 export default new SystemApi
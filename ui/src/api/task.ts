@@ -1,18 +1,65 @@
 import ajax, { Result } from './ajax'
 
+export interface PauseRecord {
+    action: string;
+    user?: string;
+    user_name?: string;
+    reason?: string;
+    time: number;
+    until?: number;
+}
+
 export interface Task {
     name: string;
     runner: string;
     handler: string;
     triggers: string[];
+    trigger: {
+        type?: number;
+        interval?: number;
+        anchor?: number;
+        at?: number;
+        jitter?: number;
+    };
+    timezone?: string;
+    calendars?: string[];
     desc?: string;
     args?: {
         name: string;
         value: string;
     }[];
     enabled: boolean;
+    parallel?: boolean;
     alerts: string[];
     maintainers?: string[];
+    misfire: {
+        policy: number;
+        limit?: number;
+    };
+    retry: {
+        attempts?: number;
+        on?: number;
+        backoff?: number;
+        interval?: number;
+    };
+    timeout: {
+        duration?: number;
+        cancel?: boolean;
+        dispatch?: number;
+    };
+    concurrency: {
+        max?: number;
+        overlap?: number;
+    };
+    pause?: PauseRecord;
+    pauses?: PauseRecord[];
+    next_fire?: number;
+}
+
+export interface PauseArgs {
+    name: string;
+    reason: string;
+    until?: number;
 }
 
 export interface SearchArgs {
@@ -33,6 +80,25 @@ export interface ExecuteArgs {
         name: string;
         value: string;
     }[];
+    at?: number | null;
+}
+
+export interface PreviewArgs {
+    name?: string;
+    triggers: string[];
+    timezone?: string;
+    calendars?: string[];
+    count?: number;
+    lang?: string;
+}
+
+export interface PreviewResult {
+    triggers: {
+        expr: string;
+        desc?: string;
+        error?: string;
+    }[];
+    fires: number[];
 }
 
 export class TaskApi {
@@ -44,6 +110,10 @@ export class TaskApi {
         return ajax.get<SearchResult>('/task/search', args)
     }
 
+    preview(args: PreviewArgs) {
+        return ajax.post<PreviewResult>('/task/preview', args)
+    }
+
     save(task: Task) {
         return ajax.post<Result<Object>>('/task/save', task)
     }
@@ -53,7 +123,15 @@ export class TaskApi {
     }
 
     execute(args: ExecuteArgs) {
-        return ajax.post<Result<Object>>('/task/execute', args)
+        return ajax.post<{ id: string }>('/task/execute', args)
+    }
+
+    pause(args: PauseArgs) {
+        return ajax.post<Result<Object>>('/task/pause', args)
+    }
+
+    resume(name: string, reason?: string) {
+        return ajax.post<Result<Object>>('/task/resume', { name, reason })
     }
 }
 
@@ -0,0 +1,92 @@
+import ajax, { Result } from './ajax'
+
+export interface WorkflowNode {
+    id: string;
+    task: string;
+    depends?: string[];
+    on_failure: number;
+    retries?: number;
+}
+
+export interface Workflow {
+    name: string;
+    desc?: string;
+    nodes: WorkflowNode[];
+    maintainers?: string[];
+    modify_time?: number;
+}
+
+export interface RunNode extends WorkflowNode {
+    status: number;
+    job?: string;
+    attempts?: number;
+}
+
+export interface WorkflowRun {
+    id: string;
+    workflow: string;
+    job?: string;
+    status: number;
+    stopped?: boolean;
+    nodes: RunNode[];
+    start_time: number;
+    end_time?: number;
+}
+
+export interface SearchArgs {
+    name?: string;
+    page_index: number;
+    page_size: number;
+}
+
+export interface SearchResult {
+    items: Workflow[];
+    total: number;
+}
+
+export interface RunSearchArgs {
+    workflow?: string;
+    page_index: number;
+    page_size: number;
+}
+
+export interface RunSearchResult {
+    items: WorkflowRun[];
+    total: number;
+}
+
+export class WorkflowApi {
+    find(name: string) {
+        return ajax.get<Workflow>('/workflow/find', { name })
+    }
+
+    search(args: SearchArgs) {
+        return ajax.get<SearchResult>('/workflow/search', args)
+    }
+
+    save(workflow: Workflow) {
+        return ajax.post<Result<Object>>('/workflow/save', workflow)
+    }
+
+    delete(name: string) {
+        return ajax.post<Result<Object>>('/workflow/delete', { name })
+    }
+
+    execute(name: string) {
+        return ajax.post<{ id: string }>('/workflow/execute', { name })
+    }
+
+    runs(args: RunSearchArgs) {
+        return ajax.get<RunSearchResult>('/workflow/runs', args)
+    }
+
+    run(id: string) {
+        return ajax.get<WorkflowRun>('/workflow/run', { id })
+    }
+
+    stop(id: string) {
+        return ajax.post<Result<Object>>('/workflow/stop', { id })
+    }
+}
+
+export default new WorkflowApi
@@ -10,7 +10,14 @@ export default {
         "task.edit": "Edit task",
         "task.delete": "Delete task",
         "task.exec": "Execute task",
+        "task.pause": "Pause task",
         "job.exec": "Execute job",
+        "job.cancel": "Cancel job",
+        "workflow.edit": "Edit workflow",
+        "workflow.delete": "Delete workflow",
+        "workflow.exec": "Execute workflow",
+        "calendar.edit": "Edit calendar",
+        "calendar.delete": "Delete calendar",
         "user.edit": "Edit user",
         "role.edit": "Edit role",
         "role.delete": "Delete role",
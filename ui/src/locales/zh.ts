@@ -10,7 +10,14 @@ export default {
         "task.edit": "编辑任务",
         "task.delete": "删除任务",
         "task.exec": "执行任务",
+        "task.pause": "暂停任务",
         "job.exec": "执行作业",
+        "job.cancel": "取消作业",
+        "workflow.edit": "编辑工作流",
+        "workflow.delete": "删除工作流",
+        "workflow.exec": "执行工作流",
+        "calendar.edit": "编辑日历",
+        "calendar.delete": "删除日历",
         "user.edit": "编辑用户",
         "role.edit": "编辑角色",
         "role.delete": "删除角色",
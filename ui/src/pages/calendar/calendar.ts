@@ -0,0 +1,13 @@
+export const weekdays = [
+  { label: "周日", value: 0 },
+  { label: "周一", value: 1 },
+  { label: "周二", value: 2 },
+  { label: "周三", value: 3 },
+  { label: "周四", value: 4 },
+  { label: "周五", value: 5 },
+  { label: "周六", value: 6 },
+]
+
+export function weekdayText(d: number) {
+  return weekdays[d]?.label || String(d)
+}
@@ -6,11 +6,29 @@ export function statusType(status: number) {
             return "success"
         case 2:
             return "error"
+        case 3:
+        case 4:
+        case 5:
+            return "warning"
         default:
             return "warning"
     }
 }
 
+export const modes = [
+    { label: '自动', value: 0, type: 'success' },
+    { label: '手动', value: 1, type: 'warning' },
+    { label: '工作流', value: 2, type: 'info' },
+]
+
+export function modeText(mode: number) {
+    return modes.find(m => m.value === mode)?.label || `异常[${mode}]`
+}
+
+export function modeType(mode: number): any {
+    return modes.find(m => m.value === mode)?.type || 'default'
+}
+
 export function statusText(status: number) {
     switch (status) {
         case 0:
@@ -19,6 +37,12 @@ export function statusText(status: number) {
             return "成功"
         case 2:
             return "失败"
+        case 3:
+            return "超时"
+        case 4:
+            return "已取消"
+        case 5:
+            return "已跳过"
         default:
             return `异常[${status}]`
     }
@@ -1,3 +1,6 @@
+import type { Task } from "@/api/task";
+import { formatZonedTime } from "@/utils/render";
+
 export const alerts = [
     { value: "email", text: "邮件" },
     { value: "wecom", text: "企业微信" },
@@ -5,4 +8,57 @@ export const alerts = [
 
 export function alertText(type: string) {
     return alerts.find(a => a.value === type)?.text
-}
\ No newline at end of file
+}
+
+export const triggerTypes = [
+    { value: 0, label: "Cron 表达式" },
+    { value: 1, label: "固定间隔" },
+    { value: 2, label: "固定延迟" },
+    { value: 3, label: "单次执行" },
+]
+
+// triggerTexts returns readable descriptions of task triggers.
+export function triggerTexts(t: Task): string[] {
+    const trigger = t.trigger || {}
+    switch (trigger.type || 0) {
+        case 1:
+            return [`每 ${trigger.interval} 秒` + (trigger.anchor ? `（起始于 ${formatZonedTime(trigger.anchor)}）` : '')]
+        case 2:
+            return [`上次作业结束 ${trigger.interval} 秒后`]
+        case 3:
+            return [trigger.at ? `${formatZonedTime(trigger.at)} 执行一次` : '']
+        default:
+            return t.triggers || []
+    }
+}
+
+export const misfirePolicies = [
+    { value: 0, label: "忽略" },
+    { value: 1, label: "补触发一次" },
+    { value: 2, label: "补触发全部" },
+]
+
+export function misfireText(policy: number) {
+    return misfirePolicies.find(p => p.value === policy)?.label
+}
+
+export const retryScopes = [
+    { value: 1, label: "调度失败" },
+    { value: 2, label: "执行失败" },
+    { value: 3, label: "调度或执行失败" },
+]
+
+export const overlaps = [
+    { value: 0, label: "跳过新作业" },
+    { value: 1, label: "排队等待" },
+    { value: 2, label: "取消运行中作业" },
+]
+
+export function overlapText(policy?: number) {
+    return overlaps.find(o => o.value === (policy || 0))?.label
+}
+
+export const backoffs = [
+    { value: 0, label: "固定间隔" },
+    { value: 1, label: "指数退避" },
+]
@@ -0,0 +1,67 @@
+export const failurePolicies = [
+    { label: '停止工作流', value: 0 },
+    { label: '继续执行', value: 1 },
+    { label: '重试节点', value: 2 },
+]
+
+export function failureText(policy: number) {
+    return failurePolicies.find(p => p.value === policy)?.label || `异常[${policy}]`
+}
+
+export function nodeStatusText(status: number) {
+    switch (status) {
+        case 0:
+            return "等待中"
+        case 1:
+            return "运行中"
+        case 2:
+            return "成功"
+        case 3:
+            return "失败"
+        case 4:
+            return "已跳过"
+        default:
+            return `异常[${status}]`
+    }
+}
+
+export function nodeStatusType(status: number) {
+    switch (status) {
+        case 1:
+            return "info"
+        case 2:
+            return "success"
+        case 3:
+            return "error"
+        case 4:
+            return "warning"
+        default:
+            return "default"
+    }
+}
+
+export function runStatusText(status: number) {
+    switch (status) {
+        case 0:
+            return "运行中"
+        case 1:
+            return "成功"
+        case 2:
+            return "失败"
+        default:
+            return `异常[${status}]`
+    }
+}
+
+export function runStatusType(status: number) {
+    switch (status) {
+        case 0:
+            return "info"
+        case 1:
+            return "success"
+        case 2:
+            return "error"
+        default:
+            return "warning"
+    }
+}
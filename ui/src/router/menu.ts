@@ -11,6 +11,9 @@ import {
     DocumentTextOutline as DocumentTextIcon,
     ConstructOutline as ConstructIcon,
     KeyOutline as KeyIcon,
+    GitNetworkOutline as GitNetworkIcon,
+    CalendarOutline as CalendarIcon,
+    ServerOutline as ServerIcon,
 } from "@vicons/ionicons5";
 
 function renderIcon(icon: any) {
@@ -104,6 +107,18 @@ export const menuOptions: MenuOption[] = [
         path: "/jobs",
         icon: renderIcon(DocumentTextIcon),
     },
+    {
+        label: "工作流",
+        key: "workflows",
+        path: "/workflows",
+        icon: renderIcon(GitNetworkIcon),
+    },
+    {
+        label: "日历管理",
+        key: "calendars",
+        path: "/calendars",
+        icon: renderIcon(CalendarIcon),
+    },
     {
         label: "账号管理",
         key: "account",
@@ -141,6 +156,12 @@ export const menuOptions: MenuOption[] = [
                 path: "/config/notice",
                 icon: renderIcon(NotificationsIcon),
             },
+            {
+                label: "调度节点",
+                key: "nodes",
+                path: "/system/nodes",
+                icon: renderIcon(ServerIcon),
+            },
             {
                 label: "高级设置",
                 key: "advance",
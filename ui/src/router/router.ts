@@ -56,6 +56,13 @@ const routes: RouteRecordRaw[] = [
       title: '通知设置',
     }
   },
+  {
+    path: "/system/nodes",
+    component: () => import('../pages/system/Nodes.vue'),
+    meta: {
+      title: '调度节点',
+    }
+  },
   {
     path: "/config/advance",
     component: () => import('../pages/config/Advance.vue'),
@@ -105,6 +112,62 @@ const routes: RouteRecordRaw[] = [
       title: '作业详情',
     }
   },
+  {
+    path: "/workflows",
+    component: () => import('../pages/workflow/List.vue'),
+    meta: {
+      title: '工作流列表',
+    }
+  },
+  {
+    path: "/workflows/new",
+    component: () => import('../pages/workflow/Edit.vue'),
+    meta: {
+      title: '新建工作流',
+    }
+  },
+  {
+    path: "/workflows/runs/:id",
+    component: () => import('../pages/workflow/Run.vue'),
+    meta: {
+      title: '运行详情',
+    }
+  },
+  {
+    path: "/workflows/:name",
+    component: () => import('../pages/workflow/View.vue'),
+    meta: {
+      title: '工作流详情',
+    }
+  },
+  {
+    path: "/workflows/:name/edit",
+    component: () => import('../pages/workflow/Edit.vue'),
+    meta: {
+      title: '工作流编辑',
+    }
+  },
+  {
+    path: "/calendars",
+    component: () => import('../pages/calendar/List.vue'),
+    meta: {
+      title: '日历列表',
+    }
+  },
+  {
+    path: "/calendars/new",
+    component: () => import('../pages/calendar/Edit.vue'),
+    meta: {
+      title: '新建日历',
+    }
+  },
+  {
+    path: "/calendars/:name/edit",
+    component: () => import('../pages/calendar/Edit.vue'),
+    meta: {
+      title: '日历编辑',
+    }
+  },
   {
     path: "/account/users",
     component: () => import('../pages/account/user/List.vue'),
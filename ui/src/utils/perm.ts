@@ -2,7 +2,14 @@ export const perms = [
     { value: "task.edit", text: "编辑任务" },
     { value: "task.delete", text: "删除任务" },
     { value: "task.exec", text: "执行任务" },
+    { value: "task.pause", text: "暂停任务" },
     { value: "job.exec", text: "执行作业" },
+    { value: "job.cancel", text: "取消作业" },
+    { value: "workflow.edit", text: "编辑工作流" },
+    { value: "workflow.delete", text: "删除工作流" },
+    { value: "workflow.exec", text: "执行工作流" },
+    { value: "calendar.edit", text: "编辑日历" },
+    { value: "calendar.delete", text: "删除日历" },
     { value: "user.edit", text: "编辑用户" },
     { value: "role.edit", text: "编辑角色" },
     { value: "role.delete", text: "删除角色" },
@@ -62,6 +62,16 @@ export function renderTime(time: number) {
     return h(NTime, { time, format: "y-MM-dd HH:mm:ss" })
 }
 
+/**
+ * Format time in specified time zone
+ * @param time milliseconds
+ * @param tz IANA time zone name, use local zone if empty
+ */
+export function formatZonedTime(time: number, tz?: string): string {
+    // sv-SE locale formats date as 'yyyy-MM-dd HH:mm:ss'
+    return new Date(time).toLocaleString('sv-SE', tz ? { timeZone: tz } : {})
+}
+
 export interface Button {
     type: "default" | "primary" | "error" | "info" | "success" | "warning",
     text: string,